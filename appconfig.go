@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AppConfigSource polls an AWS AppConfig configuration profile through the
+// AppConfig Data API. Deployments, including their canary and bake time
+// strategies, reach the service on the next poll without a restart.
+type AppConfigSource struct {
+	Application string
+	Environment string
+	Profile     string
+	// Format of the configuration; derived from its content type when
+	// empty.
+	Format Format
+	// PollInterval and Timeout default to 1m and 30s when not positive.
+	PollInterval time.Duration
+	Region       string
+	Endpoint     string
+	Timeout      time.Duration
+	Client       *http.Client
+
+	once sync.Once
+	aws  *awsClient
+
+	mu     sync.Mutex
+	token  string
+	cached map[string]string
+}
+
+const (
+	defaultAppConfigPollInterval = time.Minute
+	defaultAppConfigTimeout      = 30 * time.Second
+)
+
+func NewAppConfigSource(application, environment, profile string) *AppConfigSource {
+	return &AppConfigSource{
+		Application:  application,
+		Environment:  environment,
+		Profile:      profile,
+		PollInterval: defaultAppConfigPollInterval,
+		Timeout:      defaultAppConfigTimeout,
+	}
+}
+
+func (s *AppConfigSource) Load() (map[string]string, error) {
+	s.mu.Lock()
+	if s.cached != nil {
+		values := copyEnvs(s.cached)
+		s.mu.Unlock()
+		return values, nil
+	}
+	s.mu.Unlock()
+
+	values, _, err := s.poll(context.Background())
+	return values, err
+}
+
+func (s *AppConfigSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		s.mu.Lock()
+		interval := s.pollInterval()
+		s.mu.Unlock()
+		if !sleepContext(ctx, interval) {
+			return nil
+		}
+
+		_, updated, err := s.poll(ctx)
+		if err != nil {
+			logWatchError("AppConfig", s.Profile, err)
+			continue
+		}
+		if updated {
+			changed()
+		}
+	}
+}
+
+func (s *AppConfigSource) poll(ctx context.Context) (map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	s.once.Do(func() {
+		endpoint := s.Endpoint
+		if endpoint == "" {
+			endpoint = newAWSClient(s.Region, "", nil).serviceURL("appconfigdata")
+		}
+		s.aws = newAWSClient(s.Region, endpoint, s.Client)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == "" {
+		if err := s.startSession(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.aws.endpoint+"/configuration?configuration_token="+url.QueryEscape(s.token), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	resp, err := s.aws.do(ctx, req, "appconfig", nil)
+	if err != nil {
+		// Tokens expire after 24 hours; start a new session next time.
+		s.token = ""
+		return nil, false, fmt.Errorf("error polling AppConfig profile %s: %w", s.Profile, err)
+	}
+	defer resp.Body.Close()
+
+	s.token = resp.Header.Get("Next-Poll-Configuration-Token")
+	if seconds, err := strconv.Atoi(resp.Header.Get("Next-Poll-Interval-In-Seconds")); err == nil && seconds > 0 {
+		s.PollInterval = time.Duration(seconds) * time.Second
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading AppConfig profile %s: %w", s.Profile, err)
+	}
+	// An empty body means the configuration is unchanged since the last poll.
+	if len(data) == 0 && s.cached != nil {
+		return copyEnvs(s.cached), false, nil
+	}
+
+	format := s.Format
+	if format == "" {
+		format = FormatDotenv
+		if f, ok := formatForContentType(resp.Header.Get("Content-Type")); ok {
+			format = f
+		}
+	}
+	values, err := decodeFormat(format, data)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing AppConfig profile %s: %w", s.Profile, err)
+	}
+	s.cached = values
+	return copyEnvs(values), true, nil
+}
+
+func (s *AppConfigSource) startSession(ctx context.Context) error {
+	payload, err := json.Marshal(map[string]any{
+		"ApplicationIdentifier":                s.Application,
+		"EnvironmentIdentifier":                s.Environment,
+		"ConfigurationProfileIdentifier":       s.Profile,
+		"RequiredMinimumPollIntervalInSeconds": int(s.pollInterval().Seconds()),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.aws.endpoint+"/configurationsessions", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.aws.do(ctx, req, "appconfig", payload)
+	if err != nil {
+		return fmt.Errorf("error starting AppConfig session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		InitialConfigurationToken string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("error decoding AppConfig session: %w", err)
+	}
+	s.token = out.InitialConfigurationToken
+	return nil
+}
+
+func (s *AppConfigSource) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultAppConfigPollInterval)
+}
+
+func (s *AppConfigSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultAppConfigTimeout)
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAppConfigSource(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	bodies := []string{`{"port": 8080}`, "", `{"port": 9090}`}
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/configurationsessions":
+			var in map[string]any
+			json.NewDecoder(r.Body).Decode(&in)
+			if in["RequiredMinimumPollIntervalInSeconds"] != float64(60) {
+				t.Errorf("session request = %v", in)
+			}
+			w.Write([]byte(`{"InitialConfigurationToken": "token-0"}`))
+		case "/configuration":
+			if want := "token-" + string(rune('0'+polls)); r.URL.Query().Get("configuration_token") != want {
+				t.Errorf("configuration_token = %q, want %q", r.URL.Query().Get("configuration_token"), want)
+			}
+			polls++
+			w.Header().Set("Next-Poll-Configuration-Token", "token-"+string(rune('0'+polls)))
+			w.Header().Set("Next-Poll-Interval-In-Seconds", "30")
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(bodies[polls-1]))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	// A struct literal, without the constructor's durations.
+	s := &AppConfigSource{Application: "app", Environment: "prod", Profile: "main", Region: "us-east-1", Endpoint: srv.URL}
+	tests := []struct {
+		name        string
+		want        map[string]string
+		wantUpdated bool
+	}{
+		{"first poll", map[string]string{"PORT": "8080"}, true},
+		{"unchanged", map[string]string{"PORT": "8080"}, false},
+		{"deployment", map[string]string{"PORT": "9090"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, updated, err := s.poll(context.Background())
+			if err != nil {
+				t.Fatalf("poll: %v", err)
+			}
+			if !maps.Equal(values, tt.want) || updated != tt.wantUpdated {
+				t.Errorf("poll = %v, %v, want %v, %v", values, updated, tt.want, tt.wantUpdated)
+			}
+		})
+	}
+	if got := s.pollInterval(); got != 30*time.Second {
+		t.Errorf("pollInterval = %v, want the 30s the service asked for", got)
+	}
+}
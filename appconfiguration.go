@@ -0,0 +1,188 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const appConfigurationAPIVersion = "1.0"
+
+// AppConfigurationSource loads key-values from Azure App Configuration
+// using managed identity. Labels select the environment: values are loaded
+// for each label in order, later labels overriding earlier ones, so
+// Labels: []string{"", "production"} layers production over unlabelled
+// defaults. Watching polls SentinelKey and reloads everything once it
+// changes, as the Azure SDKs do.
+type AppConfigurationSource struct {
+	// Endpoint is the store URL, e.g. https://myapp.azconfig.io.
+	Endpoint string
+	// KeyFilter selects keys, e.g. "myapp:*". The literal part before the
+	// wildcard is stripped from key names.
+	KeyFilter string
+	// Labels default to the unlabelled key-values when empty.
+	Labels      []string
+	SentinelKey string
+	// PollInterval and Timeout default to 30s when not positive.
+	PollInterval time.Duration
+	ClientID     string
+	Timeout      time.Duration
+	Client       *http.Client
+
+	once sync.Once
+	cred *azureCredential
+
+	mu           sync.Mutex
+	sentinelETag string
+}
+
+const (
+	defaultAppConfigurationPollInterval = 30 * time.Second
+	defaultAppConfigurationTimeout      = 30 * time.Second
+)
+
+func NewAppConfigurationSource(endpoint, keyFilter string, labels ...string) *AppConfigurationSource {
+	if len(labels) == 0 {
+		labels = []string{""}
+	}
+	return &AppConfigurationSource{
+		Endpoint:     strings.TrimSuffix(endpoint, "/"),
+		KeyFilter:    keyFilter,
+		Labels:       labels,
+		PollInterval: defaultAppConfigurationPollInterval,
+		Timeout:      defaultAppConfigurationTimeout,
+	}
+}
+
+func (s *AppConfigurationSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	s.init()
+
+	prefix := strings.TrimSuffix(s.KeyFilter, "*")
+	if strings.ContainsAny(prefix, "*,") {
+		prefix = ""
+	}
+
+	envs := make(map[string]string)
+	for _, label := range s.labels() {
+		next := s.Endpoint + "/kv?" + s.query(s.KeyFilter, label)
+		for next != "" {
+			var page struct {
+				Items []struct {
+					Key   string `json:"key"`
+					Value string `json:"value"`
+				} `json:"items"`
+				NextLink string `json:"@nextLink"`
+			}
+			if err := s.cred.get(ctx, next, &page); err != nil {
+				return nil, fmt.Errorf("error reading App Configuration %s: %w", s.Endpoint, err)
+			}
+			for _, item := range page.Items {
+				envs[normalizePathKey(strings.TrimPrefix(item.Key, prefix))] = item.Value
+			}
+			next = ""
+			if page.NextLink != "" {
+				next = s.Endpoint + page.NextLink
+			}
+		}
+	}
+
+	if s.SentinelKey != "" {
+		if _, err := s.checkSentinel(ctx); err != nil {
+			return nil, fmt.Errorf("error reading App Configuration sentinel %s: %w", s.SentinelKey, err)
+		}
+	}
+	return envs, nil
+}
+
+func (s *AppConfigurationSource) Watch(ctx context.Context, changed func()) error {
+	if s.SentinelKey == "" {
+		return fmt.Errorf("App Configuration source %s has no SentinelKey to watch", s.Endpoint)
+	}
+	s.init()
+
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			modified, err := s.checkSentinel(ctx)
+			if err != nil {
+				logWatchError("App Configuration", s.Endpoint, err)
+				continue
+			}
+			if modified {
+				changed()
+			}
+		}
+	}
+}
+
+func (s *AppConfigurationSource) checkSentinel(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	header := make(http.Header)
+	if s.sentinelETag != "" {
+		header.Set("If-None-Match", s.sentinelETag)
+	}
+	labels := s.labels()
+	label := labels[len(labels)-1]
+	resp, err := s.cred.do(ctx, s.Endpoint+"/kv/"+url.PathEscape(s.SentinelKey)+"?"+s.query("", label), header)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	modified := s.sentinelETag != "" && etag != s.sentinelETag
+	s.sentinelETag = etag
+	return modified, nil
+}
+
+func (s *AppConfigurationSource) init() {
+	s.once.Do(func() { s.cred = newAzureCredential(s.Endpoint, s.ClientID, s.Client) })
+}
+
+func (s *AppConfigurationSource) query(keyFilter, label string) string {
+	query := url.Values{"api-version": {appConfigurationAPIVersion}}
+	if keyFilter != "" {
+		query.Set("key", keyFilter)
+	}
+	if label == "" {
+		// \0 selects key-values without a label.
+		label = "\x00"
+	}
+	query.Set("label", label)
+	return query.Encode()
+}
+
+func (s *AppConfigurationSource) labels() []string {
+	if len(s.Labels) == 0 {
+		return []string{""}
+	}
+	return s.Labels
+}
+
+func (s *AppConfigurationSource) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultAppConfigurationPollInterval)
+}
+
+func (s *AppConfigurationSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultAppConfigurationTimeout)
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAppConfigurationSource(t *testing.T) {
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", "")
+	t.Setenv("IDENTITY_HEADER", "secret")
+
+	sentinel := `"etag-1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.Header.Get("X-IDENTITY-HEADER") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"access_token":"azure-token","expires_in":"3600"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer azure-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// A struct literal without Labels reads the unlabelled key-values.
+		if got := r.URL.Query().Get("label"); got != "\x00" {
+			t.Errorf("label = %q, want the unlabelled selector", got)
+		}
+		switch r.URL.Path {
+		case "/kv":
+			if r.URL.Query().Get("after") == "" {
+				w.Write([]byte(`{"items":[{"key":"myapp:Database:Url","value":"postgres://db"}],"@nextLink":"/kv?after=1&label=%00"}`))
+				return
+			}
+			w.Write([]byte(`{"items":[{"key":"myapp:Port","value":"8080"}]}`))
+		case "/kv/sentinel":
+			if r.Header.Get("If-None-Match") == sentinel {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", sentinel)
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"no such key"}}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("IDENTITY_ENDPOINT", srv.URL+"/token")
+
+	// A struct literal, without the constructor's labels and durations.
+	s := &AppConfigurationSource{Endpoint: srv.URL, KeyFilter: "myapp:*", SentinelKey: "sentinel"}
+	values, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := map[string]string{"DATABASE_URL": "postgres://db", "PORT": "8080"}; !maps.Equal(values, want) {
+		t.Errorf("Load = %v, want %v", values, want)
+	}
+
+	tests := []struct {
+		name         string
+		etag         string
+		wantModified bool
+	}{
+		{"unchanged", `"etag-1"`, false},
+		{"changed", `"etag-2"`, true},
+		{"unchanged again", `"etag-2"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sentinel = tt.etag
+			modified, err := s.checkSentinel(context.Background())
+			if err != nil || modified != tt.wantModified {
+				t.Errorf("checkSentinel = %v, %v, want %v", modified, err, tt.wantModified)
+			}
+		})
+	}
+
+	s.SentinelKey = "missing"
+	if _, err := s.checkSentinel(context.Background()); err == nil || err.Error() != "unexpected status 404 Not Found: no such key" {
+		t.Errorf("checkSentinel = %v, want the service error", err)
+	}
+}
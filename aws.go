@@ -0,0 +1,405 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// awsClient signs requests with AWS Signature Version 4. Credentials are
+// resolved like the AWS SDKs do: environment variables, the shared
+// credentials file, web identity (EKS IRSA), the ECS container endpoint and
+// finally the EC2 instance profile.
+type awsClient struct {
+	region   string
+	endpoint string
+	client   *http.Client
+
+	mu    sync.Mutex
+	creds *awsCredentials
+}
+
+type awsCredentials struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+func newAWSClient(region, endpoint string, client *http.Client) *awsClient {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &awsClient{region: region, endpoint: strings.TrimSuffix(endpoint, "/"), client: client}
+}
+
+func (a *awsClient) serviceURL(service string) string {
+	if a.endpoint != "" {
+		return a.endpoint
+	}
+	return fmt.Sprintf("https://%s.%s.amazonaws.com", service, a.region)
+}
+
+// callJSON invokes an action of a JSON 1.1 protocol API such as SSM or
+// Secrets Manager.
+func (a *awsClient) callJSON(ctx context.Context, service, target string, in, out any) error {
+	payload, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.serviceURL(service)+"/", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+
+	resp, err := a.do(ctx, req, service, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (a *awsClient) do(ctx context.Context, req *http.Request, service string, payload []byte) (*http.Response, error) {
+	if a.region == "" {
+		return nil, errors.New("AWS region is not set: configure it or set AWS_REGION")
+	}
+	creds, err := a.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	signAWSRequest(req, payload, creds, a.region, service, time.Now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("AWS %s request failed: %s: %s", service, resp.Status, awsErrorMessage(body))
+	}
+	return resp, nil
+}
+
+func awsErrorMessage(body []byte) string {
+	var jsonErr struct {
+		Type     string `json:"__type"`
+		Message  string `json:"message"`
+		Message2 string `json:"Message"`
+	}
+	if json.Unmarshal(body, &jsonErr) == nil && (jsonErr.Type != "" || jsonErr.Message != "" || jsonErr.Message2 != "") {
+		return strings.TrimSpace(jsonErr.Type + " " + jsonErr.Message + jsonErr.Message2)
+	}
+	var xmlErr struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &xmlErr) == nil && xmlErr.Code != "" {
+		return xmlErr.Code + " " + xmlErr.Message
+	}
+	return strings.TrimSpace(string(body))
+}
+
+func signAWSRequest(req *http.Request, payload []byte, creds *awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalPath := req.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (a *awsClient) credentials(ctx context.Context) (*awsCredentials, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.creds != nil && (a.creds.Expiration.IsZero() || time.Until(a.creds.Expiration) > 5*time.Minute) {
+		return a.creds, nil
+	}
+
+	providers := []func(context.Context) (*awsCredentials, error){
+		a.envCredentials,
+		a.sharedCredentials,
+		a.webIdentityCredentials,
+		a.containerCredentials,
+		a.instanceCredentials,
+	}
+	for _, provider := range providers {
+		creds, err := provider(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving AWS credentials: %w", err)
+		}
+		if creds != nil {
+			a.creds = creds
+			return creds, nil
+		}
+	}
+	return nil, errors.New("no AWS credentials found")
+}
+
+func (a *awsClient) envCredentials(context.Context) (*awsCredentials, error) {
+	id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if id == "" || secret == "" {
+		return nil, nil
+	}
+	return &awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+}
+
+func (a *awsClient) sharedCredentials(context.Context) (*awsCredentials, error) {
+	file := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, nil
+		}
+		file = filepath.Join(home, ".aws", "credentials")
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil
+	}
+
+	doc, err := decodeINI(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shared credentials file: %w", err)
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	values := doc.(map[string]any)
+	id, _ := values[profile+".aws_access_key_id"].(string)
+	secret, _ := values[profile+".aws_secret_access_key"].(string)
+	if id == "" || secret == "" {
+		return nil, nil
+	}
+	token, _ := values[profile+".aws_session_token"].(string)
+	return &awsCredentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: token}, nil
+}
+
+func (a *awsClient) webIdentityCredentials(ctx context.Context) (*awsCredentials, error) {
+	tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN")
+	if tokenFile == "" || roleARN == "" {
+		return nil, nil
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading web identity token: %w", err)
+	}
+	session := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if session == "" {
+		session = "go-config-module"
+	}
+
+	query := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {session},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://sts.%s.amazonaws.com/", a.region), strings.NewReader(query.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("AssumeRoleWithWebIdentity failed: %s: %s", resp.Status, awsErrorMessage(body))
+	}
+
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("error decoding STS response: %w", err)
+	}
+	c := result.Credentials
+	return &awsCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken, Expiration: c.Expiration}, nil
+}
+
+func (a *awsClient) containerCredentials(ctx context.Context) (*awsCredentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = "http://169.254.170.2" + relative
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading container authorization token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return a.fetchJSONCredentials(req)
+}
+
+func (a *awsClient) instanceCredentials(ctx context.Context) (*awsCredentials, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, ec2MetadataEndpoint+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	resp, err := a.client.Do(tokenReq)
+	if err != nil {
+		// Not running on EC2.
+		return nil, nil
+	}
+	tokenBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+	token := string(tokenBody)
+
+	const credsPath = "/latest/meta-data/iam/security-credentials/"
+	roleReq, err := http.NewRequestWithContext(ctx, http.MethodGet, ec2MetadataEndpoint+credsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	roleReq.Header.Set("X-aws-ec2-metadata-token", token)
+	resp, err = a.client.Do(roleReq)
+	if err != nil {
+		return nil, err
+	}
+	roleBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	role := strings.TrimSpace(strings.SplitN(string(roleBody), "\n", 2)[0])
+	if resp.StatusCode != http.StatusOK || role == "" {
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ec2MetadataEndpoint+credsPath+role, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	return a.fetchJSONCredentials(req)
+}
+
+func (a *awsClient) fetchJSONCredentials(req *http.Request) (*awsCredentials, error) {
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("credentials endpoint returned %s", resp.Status)
+	}
+
+	var creds awsCredentials
+	if err := json.NewDecoder(resp.Body).Decode(&creds); err != nil {
+		return nil, fmt.Errorf("error decoding credentials: %w", err)
+	}
+	return &creds, nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The expected signatures come from the AWS Signature Version 4 test suite
+// and the IAM example in the AWS documentation.
+func TestSignAWSRequest(t *testing.T) {
+	creds := &awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		header        http.Header
+		payload       string
+		service       string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name:          "get vanilla",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/",
+			service:       "service",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "post vanilla",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			service:       "service",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:          "query sorted by key",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			service:       "service",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:          "form body",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			header:        http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			payload:       "Param1=value1",
+			service:       "service",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+		{
+			name:          "iam list users",
+			method:        http.MethodGet,
+			url:           "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08",
+			header:        http.Header{"Content-Type": {"application/x-www-form-urlencoded; charset=utf-8"}},
+			service:       "iam",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			signAWSRequest(req, []byte(tt.payload), creds, "us-east-1", tt.service, now)
+
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q, want 20150830T123600Z", got)
+			}
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/" + tt.service + "/aws4_request, " +
+				"SignedHeaders=" + tt.signedHeaders + ", Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSignAWSRequestHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		service       string
+		token         string
+		signedHeaders string
+	}{
+		{"plain", "ssm", "", "host;x-amz-date"},
+		{"session token", "ssm", "TOKEN", "host;x-amz-date;x-amz-security-token"},
+		{"s3 payload hash", "s3", "", "host;x-amz-content-sha256;x-amz-date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/bucket/key", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			creds := &awsCredentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET", SessionToken: tt.token}
+			signAWSRequest(req, nil, creds, "eu-west-1", tt.service, time.Now())
+
+			if got := req.Header.Get("X-Amz-Security-Token"); got != tt.token {
+				t.Errorf("X-Amz-Security-Token = %q, want %q", got, tt.token)
+			}
+			if tt.service == "s3" {
+				if got, want := req.Header.Get("X-Amz-Content-Sha256"), sha256Hex(nil); got != want {
+					t.Errorf("X-Amz-Content-Sha256 = %q, want %q", got, want)
+				}
+			}
+			if got := req.Header.Get("Authorization"); !strings.Contains(got, "SignedHeaders="+tt.signedHeaders+",") {
+				t.Errorf("Authorization = %q, want SignedHeaders=%s", got, tt.signedHeaders)
+			}
+		})
+	}
+}
+
+func TestAWSErrorMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"json", `{"__type":"ParameterNotFound","message":"no such parameter"}`, "ParameterNotFound no such parameter"},
+		{"json capitalized", `{"Message":"access denied"}`, "access denied"},
+		{"xml", `<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`, "NoSuchKey missing"},
+		{"text", " bad gateway \n", "bad gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := awsErrorMessage([]byte(tt.body)); got != tt.want {
+				t.Errorf("awsErrorMessage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
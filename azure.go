@@ -0,0 +1,178 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const azureMetadataEndpoint = "http://169.254.169.254"
+
+// azureCredential obtains tokens through managed identity: AKS workload
+// identity (AZURE_FEDERATED_TOKEN_FILE), the App Service / Functions
+// identity endpoint, then the VM instance metadata service.
+type azureCredential struct {
+	resource string
+	clientID string
+	client   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newAzureCredential(resource, clientID string, client *http.Client) *azureCredential {
+	if clientID == "" {
+		clientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &azureCredential{resource: resource, clientID: clientID, client: client}
+}
+
+func (a *azureCredential) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Until(a.expires) > 5*time.Minute {
+		return a.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "":
+		req, err = a.workloadIdentityRequest(ctx)
+	case os.Getenv("IDENTITY_ENDPOINT") != "":
+		query := url.Values{"api-version": {"2019-08-01"}, "resource": {a.resource}}
+		if a.clientID != "" {
+			query.Set("client_id", a.clientID)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, os.Getenv("IDENTITY_ENDPOINT")+"?"+query.Encode(), nil)
+		if err == nil {
+			req.Header.Set("X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER"))
+		}
+	default:
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {a.resource}}
+		if a.clientID != "" {
+			query.Set("client_id", a.clientID)
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, azureMetadataEndpoint+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+		if err == nil {
+			req.Header.Set("Metadata", "true")
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error obtaining Azure managed identity token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("error obtaining Azure managed identity token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string          `json:"access_token"`
+		ExpiresIn   json.RawMessage `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("error decoding Azure token: %w", err)
+	}
+	// IMDS encodes expires_in as a string, Entra ID as a number.
+	seconds, _ := strconv.Atoi(strings.Trim(string(token.ExpiresIn), `"`))
+	if seconds == 0 {
+		seconds = 3600
+	}
+
+	a.token = token.AccessToken
+	a.expires = time.Now().Add(time.Duration(seconds) * time.Second)
+	return a.token, nil
+}
+
+func (a *azureCredential) workloadIdentityRequest(ctx context.Context) (*http.Request, error) {
+	assertion, err := os.ReadFile(os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading federated token: %w", err)
+	}
+	tenant := os.Getenv("AZURE_TENANT_ID")
+	if tenant == "" || a.clientID == "" {
+		return nil, errors.New("workload identity requires AZURE_TENANT_ID and AZURE_CLIENT_ID")
+	}
+	authority := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com/"
+	}
+
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {a.clientID},
+		"scope":                 {strings.TrimSuffix(a.resource, "/") + "/.default"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(authority, "/")+"/"+tenant+"/oauth2/v2.0/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+func (a *azureCredential) get(ctx context.Context, rawURL string, out any) error {
+	resp, err := a.do(ctx, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (a *azureCredential) do(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// WithBase64Keys declares keys holding base64-encoded binary values, such
+// as SIGNING_KEY_B64, so NewConfig fails on values that do not decode.
+func WithBase64Keys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := decodeBase64(value)
+		return err
+	})
+}
+
+// GetBytes decodes a base64 value. Standard and URL-safe alphabets are
+// accepted, with or without padding.
+func (c *Config) GetBytes(key string) ([]byte, error) {
+	return getValue(c, key, decodeBase64)
+}
+
+func (c *Config) GetBytesWithFallback(key string, fallback []byte) []byte {
+	return getValueWithFallback(c, key, "base64", fallback, decodeBase64)
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	data, err := encoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("value is not valid base64")
+	}
+	return data, nil
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Load populates a user-defined struct from the same merged sources as
+// NewConfig, mapping fields through tags such as `env:"WORKERS"`. The
+// built-in DATABASE_URL and AUTH_SERVICE_URL checks do not apply.
+func Load[T any](opts ...Option) (*T, error) {
+	c, err := load(opts)
+	if err != nil {
+		return nil, err
+	}
+	var v T
+	if err := c.checkSections(reflect.TypeOf(v), c.bindPrefix(), nil); err != nil {
+		return nil, err
+	}
+	if err := errors.Join(c.checkKeys(), c.checkUnknown(reflect.TypeOf(v), c.bindPrefix()), c.runValidators(), c.environ().err(0), c.Bind(&v)); err != nil {
+		return nil, err
+	}
+	c.environ().markLoaded()
+	return &v, nil
+}
+
+// Bind populates the env-tagged fields of the struct v points to. Fields
+// whose key is not set take the value of their default tag, if any, and
+// otherwise keep their current value. Unexported fields are never touched.
+// The struct is then checked by the WithStructValidator validator, if any.
+func (c *Config) Bind(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind into %T, need a pointer to a struct", v)
+	}
+	if err := c.checkSections(rv.Elem().Type(), c.bindPrefix(), nil); err != nil {
+		return err
+	}
+	failures, done := c.environ().startBind()
+	err := errors.Join(c.bindStruct(rv.Elem(), c.bindPrefix()), c.environ().err(failures))
+	done()
+	if err != nil {
+		return err
+	}
+	return c.validateStruct(rv, c.bindPrefix())
+}
+
+// checkSections rejects a struct holding a section of its own type, such as
+// a linked list node, whose keys would nest without end. visiting holds the
+// section types the section at prefix is nested in.
+func (c *Config) checkSections(t reflect.Type, prefix string, visiting []reflect.Type) error {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if slices.Contains(visiting, t) {
+		return fmt.Errorf("cannot bind recursive section type %s at %s", t, prefix)
+	}
+	visiting = append(visiting, t)
+	for _, f := range c.structFields(t, prefix) {
+		section := f.field.Type
+		switch f.kind {
+		case sectionField:
+		case optionalSectionField:
+			section = section.Elem()
+		case sliceSectionField:
+			if section = section.Elem(); section.Kind() == reflect.Pointer {
+				section = section.Elem()
+			}
+		default:
+			continue
+		}
+		if err := c.checkSections(section, f.key, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithKeySeparator sets the separator between a nested struct's prefix and
+// its keys when binding; "_" by default, so DB_HOST binds to DB.Host.
+func WithKeySeparator(sep string) Option {
+	return func(c *Config) {
+		c.keySeparator = sep
+	}
+}
+
+type fieldKind int
+
+const (
+	valueField fieldKind = iota
+	// sectionField is a nested struct whose fields bind under its key.
+	sectionField
+	// optionalSectionField is a pointer to a section, left nil unless one
+	// of its keys is set.
+	optionalSectionField
+	// sliceSectionField is a slice of sections bound from indexed keys such
+	// as UPSTREAM_0_URL, UPSTREAM_1_URL, up to the first missing index.
+	sliceSectionField
+	// prefixMapField is a map gathering every key with a prefix, tagged
+	// `env:",prefix=HEADER_"`.
+	prefixMapField
+)
+
+type boundField struct {
+	index int
+	field reflect.StructField
+	kind  fieldKind
+	// key is the field's key, or the prefix of a section's keys.
+	key     string
+	aliases []string
+}
+
+// structFields lists the bindable fields of t with their keys. Embedded
+// structs without a tag, and fields tagged ",squash", share the prefix of
+// the struct containing them. Fields tagged `env:"-"` and unexported fields,
+// such as mutexes or caches kept next to the settings, are skipped; only
+// the exported fields of an unexported embedded struct still bind.
+func (c *Config) structFields(t reflect.Type, prefix string) []boundField {
+	var fields []boundField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		tag, tagged := field.Tag.Lookup("env")
+		if tag == "-" {
+			continue
+		}
+		var alt string
+		if !tagged && c.envconfig && field.IsExported() && !(field.Anonymous && field.Tag.Get("envconfig") == "") {
+			tag, alt, tagged = envconfigKey(field)
+		}
+		key, opts := parseEnvTag(tag)
+
+		if (field.Anonymous && !tagged || hasOption(opts, "squash")) && c.isSection(field.Type) {
+			fields = append(fields, boundField{index: i, field: field, kind: sectionField, key: prefix})
+			continue
+		}
+		if mapPrefix, ok := tagOption(opts, "prefix"); ok && tagged && field.IsExported() && field.Type.Kind() == reflect.Map {
+			fields = append(fields, boundField{index: i, field: field, kind: prefixMapField, key: prefix + key + mapPrefix})
+			continue
+		}
+		if !tagged || !field.IsExported() || key == "" || key == "-" {
+			continue
+		}
+
+		key = prefix + key
+		switch {
+		case c.isSection(field.Type):
+			fields = append(fields, boundField{index: i, field: field, kind: sectionField, key: key + c.keySep()})
+		case c.isOptionalSection(field.Type):
+			fields = append(fields, boundField{index: i, field: field, kind: optionalSectionField, key: key + c.keySep()})
+		case field.Type.Kind() == reflect.Slice && (c.isSection(field.Type.Elem()) || c.isOptionalSection(field.Type.Elem())):
+			fields = append(fields, boundField{index: i, field: field, kind: sliceSectionField, key: key + c.keySep()})
+		default:
+			var aliases []string
+			for _, alias := range tagAliases(opts) {
+				aliases = append(aliases, prefix+alias)
+			}
+			if alt != "" && prefix != "" {
+				aliases = append(aliases, alt)
+			}
+			fields = append(fields, boundField{index: i, field: field, kind: valueField, key: key, aliases: aliases})
+		}
+	}
+	return fields
+}
+
+// bindStruct binds every field before reporting, so the joined error lists
+// all missing and invalid keys.
+func (c *Config) bindStruct(v reflect.Value, prefix string) error {
+	var errs []error
+	for _, f := range c.structFields(v.Type(), prefix) {
+		switch f.kind {
+		case sectionField:
+			errs = append(errs, c.bindStruct(v.Field(f.index), f.key))
+		case optionalSectionField:
+			if !c.envconfig && !c.sectionSet(f.field.Type.Elem(), f.key) {
+				continue
+			}
+			section := reflect.New(f.field.Type.Elem())
+			if err := c.bindStruct(section.Elem(), f.key); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			v.Field(f.index).Set(section)
+		case sliceSectionField:
+			errs = append(errs, c.bindSlice(v.Field(f.index), f.key))
+		case prefixMapField:
+			errs = append(errs, c.bindPrefixMap(v.Field(f.index), f.key))
+		default:
+			errs = append(errs, c.bindField(v.Field(f.index), f))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) bindSlice(v reflect.Value, prefix string) error {
+	elem := v.Type().Elem()
+	section := elem
+	if elem.Kind() == reflect.Pointer {
+		section = elem.Elem()
+	}
+
+	var errs []error
+	slice := reflect.MakeSlice(v.Type(), 0, 0)
+	for i := 0; ; i++ {
+		itemPrefix := prefix + strconv.Itoa(i) + c.keySep()
+		if !c.sectionSet(section, itemPrefix) {
+			break
+		}
+		item := reflect.New(section)
+		if err := c.bindStruct(item.Elem(), itemPrefix); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if elem.Kind() == reflect.Pointer {
+			slice = reflect.Append(slice, item)
+		} else {
+			slice = reflect.Append(slice, item.Elem())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if slice.Len() > 0 {
+		v.Set(slice)
+	}
+	return nil
+}
+
+// bindPrefixMap fills a map with every key starting with prefix, keyed by
+// the rest of the name, so HEADER_X_REQUEST_ID becomes m["X_REQUEST_ID"].
+func (c *Config) bindPrefixMap(v reflect.Value, prefix string) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s for %s", v.Type().Key(), prefix)
+	}
+	values := c.prefixValues(prefix)
+	if len(values) == 0 {
+		return nil
+	}
+	m := reflect.MakeMapWithSize(v.Type(), len(values))
+	for name, value := range values {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := c.decodeValue(value, elem); err != nil {
+			return fmt.Errorf("invalid value for %s%s: %w", prefix, name, err)
+		}
+		m.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+	}
+	v.Set(m)
+	return nil
+}
+
+// prefixValues returns the set values of all keys starting with prefix,
+// keyed by the rest of their name. PREFIX_NAME_FILE reads PREFIX_NAME from
+// a file, as for single keys.
+func (c *Config) prefixValues(prefix string) map[string]string {
+	values := make(map[string]string)
+	for _, name := range c.keyNames() {
+		if len(name) <= len(prefix) || !strings.HasPrefix(name, prefix) && !(c.foldKeys && strings.EqualFold(name[:len(prefix)], prefix)) {
+			continue
+		}
+		if base, ok := strings.CutSuffix(name, "_FILE"); ok && len(base) > len(prefix) && c.Get(base) != "" {
+			name = base
+		}
+		if value := c.Get(name); value != "" {
+			values[name[len(prefix):]] = value
+		}
+	}
+	return values
+}
+
+func (c *Config) bindField(v reflect.Value, f boundField) error {
+	field, key := f.field, f.key
+	value := c.Get(key)
+	for _, alias := range f.aliases {
+		if value != "" {
+			break
+		}
+		value, key = c.Get(alias), alias
+	}
+	if value == "" {
+		key = f.key
+		value = field.Tag.Get("default")
+		if value == "" {
+			if isRequired(field) {
+				return fmt.Errorf("%s is not set", key)
+			}
+			return nil
+		}
+		if err := c.decodeValue(value, v); err != nil {
+			return fmt.Errorf("invalid default for %s: %w", key, err)
+		}
+		return nil
+	}
+	if err := c.decodeValue(value, v); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+// sectionSet reports whether any key of a section is set by a source.
+// Defaults do not count.
+func (c *Config) sectionSet(t reflect.Type, prefix string) bool {
+	for _, f := range c.structFields(t, prefix) {
+		switch f.kind {
+		case sectionField:
+			if c.sectionSet(f.field.Type, f.key) {
+				return true
+			}
+		case optionalSectionField:
+			if c.sectionSet(f.field.Type.Elem(), f.key) {
+				return true
+			}
+		case prefixMapField:
+			if len(c.prefixValues(f.key)) > 0 {
+				return true
+			}
+		case sliceSectionField:
+			elem := f.field.Type.Elem()
+			if elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+			if c.sectionSet(elem, f.key+"0"+c.keySep()) {
+				return true
+			}
+		default:
+			if c.Get(f.key) != "" {
+				return true
+			}
+			for _, alias := range f.aliases {
+				if c.Get(alias) != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func isRequired(field reflect.StructField) bool {
+	required, _ := strconv.ParseBool(field.Tag.Get("required"))
+	return required
+}
+
+// checkRequired reports every required field of v left at its zero
+// value, for settings that options or defaults may also provide.
+func checkRequired(v reflect.Value) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if isRequired(field) && v.Field(i).IsZero() {
+			key, _ := parseEnvTag(field.Tag.Get("env"))
+			errs = append(errs, fmt.Errorf("%s is not set", key))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) isOptionalSection(t reflect.Type) bool {
+	if t.Kind() != reflect.Pointer {
+		return false
+	}
+	if _, ok := c.decoders[t]; ok {
+		return false
+	}
+	return c.isSection(t.Elem())
+}
+
+func (c *Config) keySep() string {
+	if c.keySeparator == "" {
+		return "_"
+	}
+	return c.keySeparator
+}
+
+// isSection reports whether a struct field holds nested settings rather
+// than a single value type such as time.Time.
+func (c *Config) isSection(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == urlType {
+		return false
+	}
+	if _, ok := c.decoders[t]; ok {
+		return false
+	}
+	ptr := reflect.PointerTo(t)
+	if ptr.Implements(setterType) {
+		return false
+	}
+	if c.envconfig && ptr.Implements(envconfigDecoderType) {
+		return false
+	}
+	return !ptr.Implements(textUnmarshalerType) && !ptr.Implements(binaryUnmarshalerType)
+}
+
+// parseEnvTag splits `env:"KEY,opt,..."` into the key and its options.
+func parseEnvTag(tag string) (string, []string) {
+	key, rest, _ := strings.Cut(tag, ",")
+	var opts []string
+	if rest != "" {
+		opts = strings.Split(rest, ",")
+	}
+	return key, opts
+}
+
+// tagFlags are the options an env tag may carry besides key=value ones.
+var tagFlags = map[string]bool{"squash": true}
+
+// tagAliases returns the alternative keys of a tag. The alias list runs
+// past alias= over the following plain items, as in
+// env:"DATABASE_URL,alias=DB_URL,POSTGRES_URL".
+func tagAliases(opts []string) []string {
+	var aliases []string
+	inAliases := false
+	for _, opt := range opts {
+		opt = strings.TrimSpace(opt)
+		if alias, ok := strings.CutPrefix(opt, "alias="); ok {
+			aliases = append(aliases, alias)
+			inAliases = true
+			continue
+		}
+		if inAliases && opt != "" && !tagFlags[opt] && !strings.Contains(opt, "=") {
+			aliases = append(aliases, opt)
+			continue
+		}
+		inAliases = false
+	}
+	return aliases
+}
+
+// tagOption returns the value of a name=value tag option.
+func tagOption(opts []string, name string) (string, bool) {
+	for _, opt := range opts {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(opt), name+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func hasOption(opts []string, name string) bool {
+	for _, opt := range opts {
+		if strings.TrimSpace(opt) == name {
+			return true
+		}
+	}
+	return false
+}
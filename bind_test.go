@@ -0,0 +1,396 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindDB struct {
+	Host string `env:"HOST"`
+	Port int    `env:"PORT" default:"5432"`
+}
+
+type bindUpstream struct {
+	URL    string `env:"URL" required:"true"`
+	Weight int    `env:"WEIGHT"`
+}
+
+type bindSettings struct {
+	Name      string            `env:"NAME"`
+	DB        bindDB            `env:"DB"`
+	Cache     *bindDB           `env:"CACHE"`
+	Upstreams []bindUpstream    `env:"UPSTREAM"`
+	Backends  []*bindUpstream   `env:"BACKEND"`
+	Headers   map[string]string `env:",prefix=HEADER_"`
+	Skipped   string            `env:"-"`
+	Untagged  string
+	internal  string
+}
+
+type bindCommon struct {
+	Region string `env:"REGION"`
+}
+
+type bindSquashed struct {
+	bindCommon
+	Shared bindDB `env:"SHARED,squash"`
+	Zone   string `env:"ZONE"`
+}
+
+// bindLevel implements Setter, checking its own value.
+type bindLevel int
+
+func (l *bindLevel) Set(raw string) error {
+	switch raw {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("level must be low or high")
+	}
+	return nil
+}
+
+// bindUpper implements encoding.TextUnmarshaler.
+type bindUpper string
+
+func (u *bindUpper) UnmarshalText(text []byte) error {
+	*u = bindUpper(strings.ToUpper(string(text)))
+	return nil
+}
+
+// bindPoint is a struct decoded by WithDecoder rather than bound as a
+// section.
+type bindPoint struct{ X, Y string }
+
+type bindValues struct {
+	Timeout  time.Duration  `env:"TIMEOUT"`
+	Endpoint url.URL        `env:"ENDPOINT"`
+	Ports    []int          `env:"PORTS"`
+	Key      []byte         `env:"KEY"`
+	Limits   map[string]int `env:"LIMITS"`
+	Workers  *int           `env:"WORKERS"`
+	Ratio    float64        `env:"RATIO"`
+	Debug    bool           `env:"DEBUG"`
+	Level    bindLevel      `env:"LEVEL"`
+	Name     bindUpper      `env:"NAME"`
+	Origin   bindPoint      `env:"ORIGIN"`
+	Small    uint8          `env:"SMALL"`
+}
+
+type bindNode struct {
+	Name string    `env:"NAME"`
+	Next *bindNode `env:"NEXT"`
+}
+
+type bindRequired struct {
+	Host  string `env:"HOST" required:"true"`
+	Port  int    `env:"PORT" required:"true"`
+	Debug bool   `env:"DEBUG" default:"maybe"`
+}
+
+func TestBind(t *testing.T) {
+	withPoint := WithDecoder(func(s string) (bindPoint, error) {
+		x, y, ok := strings.Cut(s, ",")
+		if !ok {
+			return bindPoint{}, errors.New("want x,y")
+		}
+		return bindPoint{X: x, Y: y}, nil
+	})
+	workers := 4
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		opts    []Option
+		into    any
+		want    any
+		wantErr string
+	}{
+		{
+			name:   "sections and defaults",
+			values: map[string]string{"NAME": "api", "DB_HOST": "db", "SKIPPED": "x", "UNTAGGED": "x", "INTERNAL": "x"},
+			into:   &bindSettings{},
+			want:   &bindSettings{Name: "api", DB: bindDB{Host: "db", Port: 5432}},
+		},
+		{
+			name:   "pointer section is allocated when a key is set",
+			values: map[string]string{"CACHE_HOST": "redis"},
+			into:   &bindSettings{},
+			want:   &bindSettings{DB: bindDB{Port: 5432}, Cache: &bindDB{Host: "redis", Port: 5432}},
+		},
+		{
+			name: "slice sections up to the first missing index",
+			values: map[string]string{
+				"UPSTREAM_0_URL": "http://a", "UPSTREAM_1_URL": "http://b", "UPSTREAM_1_WEIGHT": "2",
+				"UPSTREAM_3_URL": "http://d",
+			},
+			into: &bindSettings{},
+			want: &bindSettings{DB: bindDB{Port: 5432}, Upstreams: []bindUpstream{{URL: "http://a"}, {URL: "http://b", Weight: 2}}},
+		},
+		{
+			name:   "slice of pointer sections",
+			values: map[string]string{"BACKEND_0_URL": "http://a"},
+			into:   &bindSettings{},
+			want:   &bindSettings{DB: bindDB{Port: 5432}, Backends: []*bindUpstream{{URL: "http://a"}}},
+		},
+		{
+			name:   "prefix map",
+			values: map[string]string{"HEADER_X_REQUEST_ID": "1", "HEADER_ACCEPT": "json", "HEADERS": "x"},
+			into:   &bindSettings{},
+			want:   &bindSettings{DB: bindDB{Port: 5432}, Headers: map[string]string{"X_REQUEST_ID": "1", "ACCEPT": "json"}},
+		},
+		{
+			name:   "unset keys keep the current value",
+			values: map[string]string{"DB_PORT": "6432"},
+			into:   &bindSettings{Name: "preset", DB: bindDB{Host: "preset"}},
+			want:   &bindSettings{Name: "preset", DB: bindDB{Host: "preset", Port: 6432}},
+		},
+		{
+			name:   "key separator",
+			values: map[string]string{"DB__HOST": "db", "DB_HOST": "wrong"},
+			opts:   []Option{WithKeySeparator("__")},
+			into:   &bindSettings{},
+			want:   &bindSettings{DB: bindDB{Host: "db", Port: 5432}},
+		},
+		{
+			name:   "embedded and squashed structs share the prefix",
+			values: map[string]string{"REGION": "eu", "HOST": "db", "ZONE": "a"},
+			into:   &bindSquashed{},
+			want:   &bindSquashed{bindCommon: bindCommon{Region: "eu"}, Shared: bindDB{Host: "db", Port: 5432}, Zone: "a"},
+		},
+		{
+			name: "value types",
+			values: map[string]string{
+				"TIMEOUT": "1m30s", "ENDPOINT": "https://api.example.com/v1", "PORTS": "80, 443",
+				"KEY": "aGVsbG8=", "LIMITS": "read:10,write:5", "WORKERS": "4", "RATIO": "0.5",
+				"DEBUG": "true", "LEVEL": "high", "NAME": "api", "ORIGIN": "1,2", "SMALL": "255",
+			},
+			opts: []Option{withPoint},
+			into: &bindValues{},
+			want: &bindValues{
+				Timeout:  90 * time.Second,
+				Endpoint: url.URL{Scheme: "https", Host: "api.example.com", Path: "/v1"},
+				Ports:    []int{80, 443},
+				Key:      []byte("hello"),
+				Limits:   map[string]int{"read": 10, "write": 5},
+				Workers:  &workers,
+				Ratio:    0.5,
+				Debug:    true,
+				Level:    2,
+				Name:     "API",
+				Origin:   bindPoint{X: "1", Y: "2"},
+				Small:    255,
+			},
+		},
+		{
+			name:    "required keys",
+			into:    &bindRequired{},
+			wantErr: "HOST is not set\nPORT is not set\ninvalid default for DEBUG: strconv.ParseBool: parsing \"maybe\": invalid syntax",
+		},
+		{
+			name:    "invalid values are all reported",
+			values:  map[string]string{"HOST": "db", "PORT": "http"},
+			into:    &bindRequired{},
+			wantErr: "invalid value for PORT: strconv.ParseInt: parsing \"http\": invalid syntax\ninvalid default for DEBUG: strconv.ParseBool: parsing \"maybe\": invalid syntax",
+		},
+		{
+			name:    "required key of a slice item",
+			values:  map[string]string{"UPSTREAM_0_WEIGHT": "1"},
+			into:    &bindSettings{},
+			wantErr: "UPSTREAM_0_URL is not set",
+		},
+		{
+			name:    "invalid key of a section",
+			values:  map[string]string{"DB_PORT": "-"},
+			into:    &bindSettings{},
+			wantErr: "invalid value for DB_PORT: strconv.ParseInt: parsing \"-\": invalid syntax",
+		},
+		{
+			name:    "Setter error",
+			values:  map[string]string{"LEVEL": "medium"},
+			into:    &bindValues{},
+			wantErr: "invalid value for LEVEL: level must be low or high",
+		},
+		{
+			name:    "decoder error",
+			values:  map[string]string{"ORIGIN": "1"},
+			opts:    []Option{withPoint},
+			into:    &bindValues{},
+			wantErr: "invalid value for ORIGIN: want x,y",
+		},
+		{
+			name:    "invalid list item",
+			values:  map[string]string{"PORTS": "80,x"},
+			into:    &bindValues{},
+			wantErr: "invalid value for PORTS: item 1: strconv.ParseInt: parsing \"x\": invalid syntax",
+		},
+		{
+			name:    "out of range",
+			values:  map[string]string{"SMALL": "256"},
+			into:    &bindValues{},
+			wantErr: "invalid value for SMALL: strconv.ParseUint: parsing \"256\": value out of range",
+		},
+		{
+			name:    "invalid map pair",
+			values:  map[string]string{"LIMITS": "read"},
+			into:    &bindValues{},
+			wantErr: "invalid value for LIMITS: \"read\" is not a key:value pair",
+		},
+		{
+			name:    "invalid URL",
+			values:  map[string]string{"ENDPOINT": "api.example.com"},
+			into:    &bindValues{},
+			wantErr: "invalid value for ENDPOINT: \"api.example.com\" is not an absolute URL, add a scheme as in \"http://api.example.com\"",
+		},
+		{
+			name:    "recursive section",
+			into:    &bindNode{},
+			wantErr: "cannot bind recursive section type config.bindNode at NEXT_",
+		},
+		{
+			name:    "not a pointer",
+			into:    bindSettings{},
+			wantErr: "cannot bind into config.bindSettings, need a pointer to a struct",
+		},
+		{
+			name:    "not a struct",
+			into:    new(string),
+			wantErr: "cannot bind into *string, need a pointer to a struct",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithEnvFile(emptyEnvFile(t)), WithOverrides(tt.values)}, tt.opts...)
+			c, err := LoadSnapshot(opts...)
+			if err != nil {
+				t.Fatalf("LoadSnapshot: %v", err)
+			}
+			err = c.Bind(tt.into)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Bind error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bind: %v", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(tt.into, tt.want) {
+				t.Errorf("Bind =\n%+v\nwant\n%+v", tt.into, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindPrefixMapKeyType(t *testing.T) {
+	var v struct {
+		Codes map[int]string `env:",prefix=CODE_"`
+	}
+	c, err := LoadSnapshot(WithEnvFile(emptyEnvFile(t)), WithOverrides(map[string]string{"CODE_1": "x"}))
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if err := c.Bind(&v); err == nil || err.Error() != "unsupported map key type int for CODE_" {
+		t.Errorf("Bind = %v, want an unsupported map key type error", err)
+	}
+}
+
+type bindEnvconfigSpec struct {
+	DatabaseURL string        `split_words:"true"`
+	MaxConns    int           `split_words:"true" default:"10"`
+	Timeout     time.Duration `envconfig:"REQUEST_TIMEOUT"`
+	Ignored     string        `ignored:"true"`
+	Debug       bool
+	Tagged      string `env:"EXPLICIT"`
+	Cache       *bindDB
+	Level       bindDecodeLevel
+}
+
+// bindDecodeLevel has envconfig's Decode method.
+type bindDecodeLevel string
+
+func (l *bindDecodeLevel) Decode(value string) error {
+	*l = bindDecodeLevel("level-" + value)
+	return nil
+}
+
+func TestBindEnvconfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		values map[string]string
+		want   bindEnvconfigSpec
+	}{
+		{
+			name: "without prefix",
+			values: map[string]string{
+				"DATABASE_URL": "postgres://db", "REQUEST_TIMEOUT": "5s", "IGNORED": "x",
+				"DEBUG": "true", "EXPLICIT": "e", "LEVEL": "2",
+			},
+			want: bindEnvconfigSpec{
+				DatabaseURL: "postgres://db", MaxConns: 10, Timeout: 5 * time.Second,
+				Debug: true, Tagged: "e", Cache: &bindDB{Port: 5432}, Level: "level-2",
+			},
+		},
+		{
+			name:   "prefix with unprefixed envconfig fallback",
+			prefix: "myapp",
+			values: map[string]string{
+				"MYAPP_DATABASE_URL": "postgres://db", "DATABASE_URL": "wrong",
+				"MYAPP_MAX_CONNS": "20", "REQUEST_TIMEOUT": "5s", "MYAPP_CACHE_HOST": "redis",
+			},
+			want: bindEnvconfigSpec{
+				DatabaseURL: "postgres://db", MaxConns: 20, Timeout: 5 * time.Second,
+				Cache: &bindDB{Host: "redis", Port: 5432},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load[bindEnvconfigSpec](
+				WithEnvFile(emptyEnvFile(t)),
+				WithOverrides(tt.values),
+				WithEnvconfigTags(tt.prefix),
+			)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Load =\n%+v\nwant\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseEnvTag(t *testing.T) {
+	tests := []struct {
+		tag         string
+		wantKey     string
+		wantAliases []string
+		wantPrefix  string
+		wantSquash  bool
+	}{
+		{tag: "PORT", wantKey: "PORT"},
+		{tag: "DATABASE_URL,alias=DB_URL,POSTGRES_URL", wantKey: "DATABASE_URL", wantAliases: []string{"DB_URL", "POSTGRES_URL"}},
+		{tag: "DATABASE_URL,alias=DB_URL,squash", wantKey: "DATABASE_URL", wantAliases: []string{"DB_URL"}, wantSquash: true},
+		{tag: "DATABASE_URL, alias=DB_URL, prefix=X_, OTHER", wantKey: "DATABASE_URL", wantAliases: []string{"DB_URL"}, wantPrefix: "X_"},
+		{tag: ",prefix=HEADER_", wantPrefix: "HEADER_"},
+		{tag: "SHARED,squash", wantKey: "SHARED", wantSquash: true},
+		{tag: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			key, opts := parseEnvTag(tt.tag)
+			prefix, _ := tagOption(opts, "prefix")
+			if key != tt.wantKey || !reflect.DeepEqual(tagAliases(opts), tt.wantAliases) || prefix != tt.wantPrefix || hasOption(opts, "squash") != tt.wantSquash {
+				t.Errorf("parseEnvTag(%q) = %q, aliases %q, prefix %q, squash %v", tt.tag, key, tagAliases(opts), prefix, hasOption(opts, "squash"))
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WithBounds declares a numeric key whose value must lie in [min, max],
+// checked by NewConfig, e.g. WithBounds("WORKERS", 1, 64).
+func WithBounds(key string, min, max float64) Option {
+	return withChecks([]string{key}, func(c *Config, value string) error {
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		switch {
+		case math.IsNaN(n):
+			return fmt.Errorf("%q is not a number", value)
+		case n < min && math.IsInf(max, 1):
+			return fmt.Errorf("%s is less than %s", value, formatBound(min))
+		case n > max && math.IsInf(min, -1):
+			return fmt.Errorf("%s is greater than %s", value, formatBound(max))
+		case n < min || n > max:
+			return fmt.Errorf("%s is not between %s and %s", value, formatBound(min), formatBound(max))
+		}
+		return nil
+	})
+}
+
+// WithMin declares a numeric key that must be at least min.
+func WithMin(key string, min float64) Option {
+	return WithBounds(key, min, math.Inf(1))
+}
+
+// WithMax declares a numeric key that must be at most max.
+func WithMax(key string, max float64) Option {
+	return WithBounds(key, math.Inf(-1), max)
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
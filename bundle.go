@@ -0,0 +1,194 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Bundle is an archive of configuration files, certificates and templates
+// shipped as one unit. Files with a known config extension are merged as a
+// source in lexical order; every file is available through File.
+type Bundle struct {
+	files map[string][]byte
+}
+
+// OpenBundle reads a .zip, .tar, .tar.gz or .tgz archive. When publicKey is
+// set, the archive must be accompanied by an Ed25519 signature in
+// path+".sig", either raw or base64 encoded.
+func OpenBundle(file string, publicKey ed25519.PublicKey) (*Bundle, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config bundle: %w", err)
+	}
+
+	if publicKey != nil {
+		if err := verifyBundle(file, data, publicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	var files map[string][]byte
+	switch name := strings.ToLower(file); {
+	case strings.HasSuffix(name, ".zip"):
+		files, err = readZipBundle(data)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(bytes.NewReader(data))
+		if err == nil {
+			files, err = readTarBundle(gz)
+		}
+	case strings.HasSuffix(name, ".tar"):
+		files, err = readTarBundle(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported config bundle type: %s", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking config bundle %s: %w", file, err)
+	}
+	return &Bundle{files: files}, nil
+}
+
+func WithBundle(file string, publicKey ed25519.PublicKey) Option {
+	return func(c *Config) {
+		if file == "" {
+			return
+		}
+		c.sources = append(c.sources, SourceFunc(func() (map[string]string, error) {
+			b, err := OpenBundle(file, publicKey)
+			if err != nil {
+				return nil, err
+			}
+			c.bundle = b
+			return b.Load()
+		}))
+	}
+}
+
+func (c *Config) Bundle() *Bundle {
+	return c.bundle
+}
+
+func (b *Bundle) Names() []string {
+	names := make([]string, 0, len(b.files))
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (b *Bundle) File(name string) ([]byte, error) {
+	data, ok := b.files[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in config bundle: %w", name, os.ErrNotExist)
+	}
+	return data, nil
+}
+
+func (b *Bundle) Load() (map[string]string, error) {
+	envs := make(map[string]string)
+	for _, name := range b.Names() {
+		format, ok := formatsByExt[strings.ToLower(path.Ext(name))]
+		if !ok {
+			continue
+		}
+		values, err := decodeFormat(format, b.files[name])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s in config bundle: %w", name, err)
+		}
+		overlayEnvs(envs, values, nil, ",")
+	}
+	return envs, nil
+}
+
+func verifyBundle(file string, data []byte, publicKey ed25519.PublicKey) error {
+	sig, err := os.ReadFile(file + ".sig")
+	if err != nil {
+		return fmt.Errorf("error reading config bundle signature: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("invalid config bundle signature: %w", err)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(publicKey, data, sig) {
+		return errors.New("config bundle signature verification failed")
+	}
+	return nil
+}
+
+func readZipBundle(data []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name, err := bundleEntryName(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+func readTarBundle(r io.Reader) (map[string][]byte, error) {
+	tr := tar.NewReader(r)
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, err := bundleEntryName(hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+}
+
+func bundleEntryName(name string) (string, error) {
+	clean := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid entry name %q", name)
+	}
+	return clean, nil
+}
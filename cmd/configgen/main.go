@@ -0,0 +1,261 @@
+// Configgen generates typed option functions, key constants and a
+// constructor for a struct bound by config.Load. Add a directive next to
+// the struct and run go generate:
+//
+//	//go:generate go run github.com/baditaflorin/go-config-module/cmd/configgen -type Settings
+//
+// For every env-tagged field of a supported type it emits a key constant
+// (SettingsWorkersKey) and an option (WithWorkers) setting the key through
+// config.WithValue, plus NewSettingsConfig wrapping config.Load[Settings].
+// Nested sections and types without a known string form are skipped with a
+// warning.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const configPath = "github.com/baditaflorin/go-config-module"
+
+var (
+	typeName = flag.String("type", "", "name of the struct type; required")
+	output   = flag.String("output", "", "output file name; default <type>_options.go")
+	prefix   = flag.String("prefix", "", "prefix for option names, e.g. DB for WithDBHost")
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("configgen: ")
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if args := flag.Args(); len(args) > 0 {
+		dir = args[0]
+	}
+	out := *output
+	if out == "" {
+		out = strings.ToLower(*typeName) + "_options.go"
+	}
+	out = filepath.Join(dir, out)
+
+	pkg, st, imports, err := findStruct(dir, *typeName, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(pkg, *typeName, *prefix, st, imports)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// findStruct parses the package in dir and returns its name, the struct
+// type and the imports of the file declaring it, keyed by local name.
+func findStruct(dir, name, skip string) (string, *ast.StructType, map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || filepath.Clean(file) == filepath.Clean(skip) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return "", nil, nil, fmt.Errorf("%s is not a struct type", name)
+				}
+				return f.Name.Name, st, fileImports(f), nil
+			}
+		}
+	}
+	return "", nil, nil, fmt.Errorf("type %s not found in %s", name, dir)
+}
+
+func fileImports(f *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if path == configPath {
+			name = "config"
+		}
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+type option struct {
+	field, key string
+	// typ is the parameter type and format the expression turning the
+	// parameter into the key's value.
+	typ, format string
+	imports     []string
+}
+
+func generate(pkg, name, prefix string, st *ast.StructType, imports map[string]string) ([]byte, error) {
+	var options []option
+	for _, field := range st.Fields.List {
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+		tag, _ := strconv.Unquote(field.Tag.Value)
+		key, opts, _ := strings.Cut(reflect.StructTag(tag).Get("env"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			opt, ok := fieldOption(field.Type, imports)
+			if !ok || strings.Contains(opts, "prefix=") {
+				log.Printf("skipping %s.%s: unsupported type", name, ident.Name)
+				continue
+			}
+			opt.field, opt.key = ident.Name, key
+			options = append(options, opt)
+		}
+	}
+
+	needed := make(map[string]bool)
+	for _, opt := range options {
+		for _, path := range opt.imports {
+			needed[path] = true
+		}
+	}
+	var paths []string
+	for path := range needed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by configgen -type %s; DO NOT EDIT.\n\n", name)
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", pkg)
+	for _, path := range paths {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	if len(paths) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\tconfig %q\n)\n\n", configPath)
+
+	if len(options) > 0 {
+		fmt.Fprintf(&b, "// Keys bound into %s.\nconst (\n", name)
+		for _, opt := range options {
+			fmt.Fprintf(&b, "\t%s%sKey = %q\n", name, opt.field, opt.key)
+		}
+		b.WriteString(")\n\n")
+	}
+	for _, opt := range options {
+		param := paramName(opt.field)
+		fmt.Fprintf(&b, "// With%s%s sets %s unless a source or environment variable sets it.\n", prefix, opt.field, opt.key)
+		fmt.Fprintf(&b, "func With%s%s(%s %s) config.Option {\n", prefix, opt.field, param, opt.typ)
+		fmt.Fprintf(&b, "\treturn config.WithValue(%s%sKey, %s)\n}\n\n", name, opt.field, fmt.Sprintf(opt.format, param))
+	}
+
+	fmt.Fprintf(&b, "// New%sConfig loads %s from the environment and the given sources.\n", strings.TrimSuffix(name, "Config"), name)
+	fmt.Fprintf(&b, "func New%sConfig(opts ...config.Option) (*%s, error) {\n", strings.TrimSuffix(name, "Config"), name)
+	fmt.Fprintf(&b, "\treturn config.Load[%s](opts...)\n}\n", name)
+
+	return format.Source(b.Bytes())
+}
+
+// fieldOption reports how an option parameter of the given type becomes a
+// value. In format, %s stands for the parameter.
+func fieldOption(expr ast.Expr, imports map[string]string) (option, bool) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return option{typ: "string", format: "%s"}, true
+		case "bool":
+			return option{typ: "bool", format: "strconv.FormatBool(%s)", imports: []string{"strconv"}}, true
+		case "int", "int8", "int16", "int32", "int64":
+			return option{typ: t.Name, format: "strconv.FormatInt(int64(%s), 10)", imports: []string{"strconv"}}, true
+		case "uint", "uint8", "uint16", "uint32", "uint64":
+			return option{typ: t.Name, format: "strconv.FormatUint(uint64(%s), 10)", imports: []string{"strconv"}}, true
+		case "float32":
+			return option{typ: t.Name, format: "strconv.FormatFloat(float64(%s), 'g', -1, 32)", imports: []string{"strconv"}}, true
+		case "float64":
+			return option{typ: t.Name, format: "strconv.FormatFloat(%s, 'g', -1, 64)", imports: []string{"strconv"}}, true
+		}
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			break
+		}
+		switch path := imports[pkg.Name]; {
+		case path == "time" && t.Sel.Name == "Duration":
+			return option{typ: "time.Duration", format: "%s.String()", imports: []string{"time"}}, true
+		case path == configPath && t.Sel.Name == "Secret":
+			return option{typ: "config.Secret", format: "%s.Reveal()"}, true
+		}
+	case *ast.ArrayType:
+		if elt, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && elt.Name == "string" {
+			return option{typ: "[]string", format: `strings.Join(%s, ",")`, imports: []string{"strings"}}, true
+		}
+	}
+	return option{}, false
+}
+
+// reserved are the package names a generated option may refer to.
+var reserved = map[string]bool{"config": true, "strconv": true, "strings": true, "time": true}
+
+// paramName lower-cases the leading word of a field name, so Workers
+// becomes workers and HTTPTimeout becomes httpTimeout.
+func paramName(field string) string {
+	runes := []rune(field)
+	for i := range runes {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	name := string(runes)
+	if token.IsKeyword(name) || reserved[name] {
+		name += "Value"
+	}
+	return name
+}
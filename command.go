@@ -0,0 +1,77 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+type FailurePolicy int
+
+const (
+	// FailOnError makes NewConfig fail when the command fails.
+	FailOnError FailurePolicy = iota
+	// WarnOnError logs a warning and leaves the key unset.
+	WarnOnError
+)
+
+// CommandSource sets a single key from the stdout of a command, e.g. a
+// secret manager CLI such as `op read` or `pass show`. The trailing newline
+// is trimmed.
+type CommandSource struct {
+	Key     string
+	Command string
+	Args    []string
+	// Timeout defaults to 30s when not positive.
+	Timeout   time.Duration
+	OnFailure FailurePolicy
+}
+
+const defaultCommandTimeout = 30 * time.Second
+
+func NewCommandSource(key, command string, args ...string) *CommandSource {
+	return &CommandSource{
+		Key:     key,
+		Command: command,
+		Args:    args,
+		Timeout: defaultCommandTimeout,
+	}
+}
+
+func WithCommand(key, command string, args ...string) Option {
+	return WithSource(NewCommandSource(key, command, args...))
+}
+
+func (s *CommandSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.Command, s.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", s.timeout())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		err = fmt.Errorf("command for %s failed: %w", s.Key, err)
+		if s.OnFailure == WarnOnError {
+			log.Printf("Warning: %v", err)
+			return make(map[string]string), nil
+		}
+		return nil, err
+	}
+	return map[string]string{s.Key: strings.TrimRight(stdout.String(), "\r\n")}, nil
+}
+
+func (s *CommandSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultCommandTimeout)
+}
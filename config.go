@@ -1,22 +1,96 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
-	DatabaseURL    string
-	AuthServiceURL string
-	Debug          bool
-	Port           string
-	EnvFile        string
+	DatabaseURL Secret `env:"DATABASE_URL" required:"true"`
+	// Database is DatabaseURL parsed, set by NewConfig.
+	Database       *DSN
+	AuthServiceURL string `env:"AUTH_SERVICE_URL" required:"true"`
+	// AuthServiceEndpoint is AuthServiceURL parsed, set by NewConfig.
+	AuthServiceEndpoint *url.URL
+	Debug               bool   `env:"DEBUG"`
+	Port                string `env:"PORT"`
+	EnvFile             string
+	LayeredEnv          bool
+	HTTPTimeout         time.Duration `env:"HTTP_TIMEOUT"`
+	ShutdownGrace       time.Duration `env:"SHUTDOWN_GRACE"`
+	// LogFormat is "json", "text" or "console".
+	LogFormat string     `env:"LOG_FORMAT"`
+	LogLevel  slog.Level `env:"LOG_LEVEL"`
+
+	opts            []Option
+	sources         []Source
+	defaults        []Source
+	bundle          *Bundle
+	resolved        *resolvedEnvs
+	timeLayouts     []string
+	listSeparator   string
+	appendLists     map[string]bool
+	keySeparator    string
+	foldKeys        bool
+	interpolate     bool
+	strict          bool
+	strictPrefix    string
+	knownKeys       []string
+	envconfig       bool
+	envconfigPrefix string
+	precedence      []Layer
+	envPrefix       string
+	envFallback     bool
+	flags           []*flag.FlagSet
+	profile         string
+	profiles        []profile
+	decoders        map[reflect.Type]func(string) (any, error)
+	checks          []keyCheck
+	validators      []Validator
+	deprecated      []deprecatedKey
+	warnings        []error
+	structValidator StructValidator
+	regexps         map[string]*regexp.Regexp
+	secretKeys      []string
+	// explicit holds the built-in keys set by an option, such as WithLogLevel,
+	// whose zero value is also a valid setting.
+	explicit map[string]bool
+}
+
+// resolvedEnvs holds the loaded values behind a pointer, so that printing a
+// Config with %+v shows an address rather than every raw value.
+type resolvedEnvs struct {
+	envs     map[string]string
+	defaults map[string]string
+	// values and overrides hold the keys set by WithValue and WithOverrides.
+	values    map[string]string
+	overrides map[string]string
+	// environ is the OS environment as narrowed by WithEnvPrefix, or nil.
+	environ *environment
+	// sourced holds the keys of envs set by flags, files, sources or
+	// overrides, for Export to tell them from the process environment that
+	// a custom precedence or a snapshot merges in.
+	sourced map[string]bool
+	// frozen marks a snapshot, whose envs already hold the OS environment
+	// and the contents of KEY_FILE files.
+	frozen bool
+}
+
+// keyCheck validates the value of a declared key during NewConfig.
+type keyCheck struct {
+	key   string
+	check func(c *Config, value string) error
 }
 
 type Option func(*Config)
@@ -24,7 +98,7 @@ type Option func(*Config)
 func WithDatabaseURL(url string) Option {
 	return func(c *Config) {
 		if url != "" {
-			c.DatabaseURL = url
+			c.DatabaseURL = Secret(url)
 		}
 	}
 }
@@ -40,6 +114,7 @@ func WithAuthServiceURL(url string) Option {
 func WithDebug(debug bool) Option {
 	return func(c *Config) {
 		c.Debug = debug
+		c.setExplicit("DEBUG")
 	}
 }
 
@@ -59,52 +134,261 @@ func WithEnvFile(file string) Option {
 	}
 }
 
+func WithLayeredEnv(enabled bool) Option {
+	return func(c *Config) {
+		c.LayeredEnv = enabled
+	}
+}
+
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		if timeout > 0 {
+			c.HTTPTimeout = timeout
+		}
+	}
+}
+
+func WithShutdownGrace(grace time.Duration) Option {
+	return func(c *Config) {
+		if grace > 0 {
+			c.ShutdownGrace = grace
+		}
+	}
+}
+
+func WithLogFormat(format string) Option {
+	return func(c *Config) {
+		if format != "" {
+			c.LogFormat = format
+		}
+	}
+}
+
+// WithValue sets any key with the precedence of the options above: sources
+// and environment variables override it, defaults do not. The options
+// generated by cmd/configgen for user-defined structs are built on it.
+func WithValue(key, value string) Option {
+	return func(c *Config) {
+		if value == "" {
+			return
+		}
+		if c.resolved.values == nil {
+			c.resolved.values = make(map[string]string)
+		}
+		c.resolved.values[key] = value
+	}
+}
+
+// WithOverrides pins keys above every source, the environment and flags,
+// KEY_FILE variables included, e.g. for tests or tools embedding a
+// service. Later calls add to and override earlier ones.
+func WithOverrides(overrides map[string]string) Option {
+	return func(c *Config) {
+		if c.resolved.overrides == nil {
+			c.resolved.overrides = make(map[string]string)
+		}
+		mergeEnvs(c.resolved.overrides, overrides)
+	}
+}
+
+var logFormats = []string{"json", "text", "console"}
+
 func NewConfig(opts ...Option) (*Config, error) {
-	c := &Config{}
+	c, err := load(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate loads and checks the configuration exactly as NewConfig does
+// but does not return it, for a --check-config flag or a CI gate.
+func Validate(opts ...Option) error {
+	c, err := load(opts)
+	if err != nil {
+		return err
+	}
+	return c.validate()
+}
+
+// load resolves every source without validating the built-in settings, so
+// it also serves Load for user-defined structs.
+func load(opts []Option) (*Config, error) {
+	c := &Config{opts: opts, resolved: &resolvedEnvs{environ: &environment{}}}
 
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.envPrefix != "" {
+		c.resolved.environ.vars = prefixedEnvironment(c.envPrefix, c.envFallback)
+	}
 
-	envs, err := loadEnv(c.EnvFile)
+	envFile := resolveEnvFile(c.EnvFile)
+	dotenv, err := loadEnv(envFile, c.interpolate, c.overlay)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load environment: %w", err)
 	}
+	if c.LayeredEnv {
+		layers, err := c.loadEnvLayers(envFile, dotenv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load environment: %w", err)
+		}
+		c.overlay(dotenv, layers)
+	}
+	c.applyProfile(dotenv)
 
-	c.DatabaseURL = getEnvWithFallback(envs, "DATABASE_URL", c.DatabaseURL)
-	c.AuthServiceURL = getEnvWithFallback(envs, "AUTH_SERVICE_URL", c.AuthServiceURL)
-	c.Debug = getBoolEnvWithFallback(envs, "DEBUG", c.Debug)
-	c.Port = getEnvWithFallback(envs, "PORT", c.Port)
+	sources, err := c.loadSources(c.sources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration source: %w", err)
+	}
 
-	if err := c.validate(); err != nil {
+	credentials, err := loadCredentials(os.Getenv("CREDENTIALS_DIRECTORY"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credentials: %w", err)
+	}
+	layers := map[Layer]map[string]string{
+		FlagsLayer:       c.flagValues(),
+		CredentialsLayer: credentials,
+		DotEnvLayer:      dotenv,
+		SourcesLayer:     sources,
+	}
+	sourced := make(map[string]bool)
+	for _, layer := range layers {
+		for key := range layer {
+			sourced[key] = true
+		}
+	}
+	envs := c.mergeLayers(layers)
+	hideLower(envs, c.resolved.overrides)
+	mergeEnvs(envs, c.resolved.overrides)
+	for key := range c.resolved.overrides {
+		sourced[key] = true
+	}
+	c.applyDeprecated(envs, sourced)
+
+	defaults, err := c.loadSources(c.defaults)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load defaults: %w", err)
+	}
+	mergeEnvs(defaults, c.resolved.values)
+	if c.interpolate {
+		if err := interpolateEnvs(c.environ(), envs, defaults); err != nil {
+			return nil, err
+		}
+	}
+	c.applyDefaults(defaults)
+	c.resolved.envs, c.resolved.defaults, c.resolved.sourced = envs, defaults, sourced
+	c.setBuiltins(c.environ(), envs)
+	if err := c.environ().err(0); err != nil {
 		return nil, err
 	}
 
 	return c, nil
 }
 
+// setBuiltins sets the built-in fields from envs and env, keeping their
+// current values for unset keys.
+func (c *Config) setBuiltins(env *environment, envs map[string]string) {
+	c.DatabaseURL = Secret(getEnvWithFallback(env, envs, "DATABASE_URL", c.DatabaseURL.Reveal()))
+	c.AuthServiceURL = getEnvWithFallback(env, envs, "AUTH_SERVICE_URL", c.AuthServiceURL)
+	c.Debug = getBoolEnvWithFallback(env, envs, "DEBUG", c.Debug)
+	c.Port = getEnvWithFallback(env, envs, "PORT", c.Port)
+	c.HTTPTimeout = getDurationEnvWithFallback(env, envs, "HTTP_TIMEOUT", c.HTTPTimeout)
+	c.ShutdownGrace = getDurationEnvWithFallback(env, envs, "SHUTDOWN_GRACE", c.ShutdownGrace)
+	c.LogFormat = getEnumEnvWithFallback(env, envs, "LOG_FORMAT", c.LogFormat, logFormats...)
+	c.LogLevel = getLogLevelEnvWithFallback(env, envs, "LOG_LEVEL", c.LogLevel)
+}
+
+// validate reports every problem at once, joined with errors.Join, so that
+// a deployment can be fixed in one go.
 func (c *Config) validate() error {
-	if c.DatabaseURL == "" {
-		return fmt.Errorf("DATABASE_URL is not set")
+	errs := append([]error{checkRequired(reflect.ValueOf(c).Elem())}, c.parseBuiltins()...)
+	errs = append(errs, c.checkKeys(), c.checkUnknown(reflect.TypeOf(*c), ""), c.runValidators(), c.environ().err(0))
+	c.environ().markLoaded()
+	return errors.Join(errs...)
+}
+
+// parseBuiltins parses the built-in URLs and port that are set, filling in
+// Database and AuthServiceEndpoint.
+func (c *Config) parseBuiltins() []error {
+	var errs []error
+	if c.DatabaseURL != "" {
+		dsn, err := parseDSN(c.DatabaseURL.Reveal())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid DATABASE_URL: %w", err))
+		}
+		c.Database = dsn
+	}
+	if c.Port != "" {
+		if _, err := parsePort(c.Port); err != nil {
+			errs = append(errs, fmt.Errorf("invalid PORT: %w", err))
+		}
+	}
+	if c.AuthServiceURL != "" {
+		endpoint, err := parseEndpoint(c.AuthServiceURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid AUTH_SERVICE_URL: %w", err))
+		}
+		c.AuthServiceEndpoint = endpoint
 	}
-	if c.AuthServiceURL == "" {
-		return fmt.Errorf("AUTH_SERVICE_URL is not set")
+	return errs
+}
+
+func (c *Config) checkKeys() error {
+	var errs []error
+	for _, check := range c.checks {
+		if value := c.Get(check.key); value != "" {
+			if err := check.check(c, value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value for %s: %w", check.key, err))
+			}
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-func getEnvWithFallback(envs map[string]string, key, fallback string) string {
+func withChecks(keys []string, check func(c *Config, value string) error) Option {
+	return func(c *Config) {
+		for _, key := range keys {
+			c.checks = append(c.checks, keyCheck{key: key, check: check})
+		}
+	}
+}
+
+func getEnvWithFallback(env *environment, envs map[string]string, key, fallback string) string {
+	if value, exists := lookupFileEnv(env, envs, key); exists {
+		return value
+	}
 	if value, exists := envs[key]; exists && value != "" {
 		return value
 	}
-	if value, exists := os.LookupEnv(key); exists && value != "" {
+	if value, exists := env.lookup(key); exists && value != "" {
 		return value
 	}
 	return fallback
 }
 
-func getBoolEnvWithFallback(envs map[string]string, key string, fallback bool) bool {
-	strValue := getEnvWithFallback(envs, key, strconv.FormatBool(fallback))
+// lookupFileEnv implements the KEY_FILE convention used by Docker and
+// Kubernetes secrets: when KEY_FILE is set, the value of KEY is read from
+// the file it points to. KEY_FILE only beats KEY within a layer; envs holds
+// the one of the two set by the highest layer, see mergeLayers, so the OS
+// environment's KEY_FILE only counts when no other layer sets KEY.
+func lookupFileEnv(env *environment, envs map[string]string, key string) (string, bool) {
+	path, exists := envs[key+"_FILE"]
+	if (!exists || path == "") && envs[key] == "" {
+		path, exists = env.lookup(key + "_FILE")
+	}
+	if !exists || path == "" {
+		return "", false
+	}
+
+	return env.readFile(key, path)
+}
+
+func getBoolEnvWithFallback(env *environment, envs map[string]string, key string, fallback bool) bool {
+	strValue := getEnvWithFallback(env, envs, key, strconv.FormatBool(fallback))
 	boolValue, err := strconv.ParseBool(strValue)
 	if err != nil {
 		log.Printf("Warning: invalid boolean value for %s, using fallback", key)
@@ -113,7 +397,46 @@ func getBoolEnvWithFallback(envs map[string]string, key string, fallback bool) b
 	return boolValue
 }
 
-func loadEnv(envFile string) (map[string]string, error) {
+func getDurationEnvWithFallback(env *environment, envs map[string]string, key string, fallback time.Duration) time.Duration {
+	strValue := getEnvWithFallback(env, envs, key, fallback.String())
+	durationValue, err := time.ParseDuration(strValue)
+	if err != nil {
+		log.Printf("Warning: invalid duration value for %s, using fallback", key)
+		return fallback
+	}
+	return durationValue
+}
+
+func getEnumEnvWithFallback(env *environment, envs map[string]string, key, fallback string, allowed ...string) string {
+	strValue := getEnvWithFallback(env, envs, key, fallback)
+	if strValue == "" {
+		return ""
+	}
+	value, err := parseEnum(strValue, allowed)
+	if err != nil {
+		log.Printf("Warning: invalid value for %s (%v), using fallback", key, err)
+		return fallback
+	}
+	return value
+}
+
+func getLogLevelEnvWithFallback(env *environment, envs map[string]string, key string, fallback slog.Level) slog.Level {
+	strValue := getEnvWithFallback(env, envs, key, fallback.String())
+	level, err := parseLogLevel(strValue)
+	if err != nil {
+		log.Printf("Warning: invalid log level for %s, using fallback", key)
+		return fallback
+	}
+	return level
+}
+
+func mergeEnvs(dst, src map[string]string) {
+	for key, value := range src {
+		dst[key] = value
+	}
+}
+
+func resolveEnvFile(envFile string) string {
 	if envFile == "" {
 		envFile = os.Getenv("ENV_FILE")
 		if envFile == "" {
@@ -122,8 +445,13 @@ func loadEnv(envFile string) (map[string]string, error) {
 			envFile = filepath.Join(basepath, "../..", ".env")
 		}
 	}
+	return envFile
+}
+
+func loadEnv(envFile string, verbatim bool, overlay overlayFunc) (map[string]string, error) {
+	envFile = resolveEnvFile(envFile)
 
-	envs, err := godotenv.Read(envFile)
+	envs, err := readEnvFile(envFile, verbatim, overlay)
 	if err != nil {
 		if os.IsNotExist(err) {
 			log.Printf("Warning: .env file not found at %s, using only OS environment variables", envFile)
@@ -133,3 +461,23 @@ func loadEnv(envFile string) (map[string]string, error) {
 	}
 	return envs, nil
 }
+
+func (c *Config) loadEnvLayers(envFile string, base map[string]string) (map[string]string, error) {
+	layers := []string{envFile + ".local"}
+	if appEnv := getEnvWithFallback(c.environ(), base, "APP_ENV", ""); appEnv != "" {
+		layers = append(layers, envFile+"."+appEnv)
+	}
+
+	envs := make(map[string]string)
+	for _, layer := range layers {
+		values, err := readEnvFile(layer, c.interpolate, c.overlay)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %w", layer, err)
+		}
+		c.overlay(envs, values)
+	}
+	return envs, nil
+}
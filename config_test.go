@@ -0,0 +1,87 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("PORT=7000\nWORKERS=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "token")
+	if err := os.WriteFile(secret, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORT", "7001")
+	t.Setenv("API_TOKEN_FILE", secret)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("port", "", "")
+	flags.String("workers", "", "")
+	if err := flags.Parse([]string{"--port=7002", "--workers=3"}); err != nil {
+		t.Fatal(err)
+	}
+	base := []Option{
+		WithEnvFile(envFile),
+		WithFlags(flags),
+		WithDatabaseURL("postgres://db"),
+		WithAuthServiceURL("http://auth"),
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "without overrides",
+			want: map[string]string{"PORT": "7002", "WORKERS": "3", "API_TOKEN": "from-file"},
+		},
+		{
+			name: "beat flags, .env, the environment and KEY_FILE",
+			opts: []Option{WithOverrides(map[string]string{"PORT": "9090", "API_TOKEN": "pinned"})},
+			want: map[string]string{"PORT": "9090", "WORKERS": "3", "API_TOKEN": "pinned"},
+		},
+		{
+			name: "later calls add and override",
+			opts: []Option{
+				WithOverrides(map[string]string{"PORT": "9090", "WORKERS": "8"}),
+				WithOverrides(map[string]string{"PORT": "9091"}),
+			},
+			want: map[string]string{"PORT": "9091", "WORKERS": "8"},
+		},
+		{
+			name:    "pinned values are still checked",
+			opts:    []Option{WithOverrides(map[string]string{"PORT": "0"})},
+			wantErr: `invalid PORT: "0" is not a port number between 1 and 65535`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewConfig(append(base, tt.opts...)...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("NewConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewConfig: %v", err)
+			}
+			for key, want := range tt.want {
+				if got := c.Get(key); got != want {
+					t.Errorf("Get(%q) = %q, want %q", key, got, want)
+				}
+			}
+			if tt.want["PORT"] != "" && c.Port != tt.want["PORT"] {
+				t.Errorf("Port = %q, want %q", c.Port, tt.want["PORT"])
+			}
+		})
+	}
+}
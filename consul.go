@@ -0,0 +1,178 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsulSource loads every key under Prefix from Consul KV. Watching uses
+// blocking queries, so changes are picked up as soon as Consul reports a
+// new index.
+type ConsulSource struct {
+	Address string
+	Prefix  string
+	Token   string
+	// WaitTime bounds each blocking query while watching. WaitTime and
+	// Timeout default to 5m and 10s when not positive.
+	WaitTime time.Duration
+	Timeout  time.Duration
+	Client   *http.Client
+
+	mu    sync.Mutex
+	index uint64
+}
+
+const (
+	defaultConsulWaitTime = 5 * time.Minute
+	defaultConsulTimeout  = 10 * time.Second
+)
+
+// NewConsulSource defaults the address and token to CONSUL_HTTP_ADDR and
+// CONSUL_HTTP_TOKEN, like the Consul CLI.
+func NewConsulSource(prefix string) *ConsulSource {
+	address := os.Getenv("CONSUL_HTTP_ADDR")
+	if address == "" {
+		address = "http://127.0.0.1:8500"
+	} else if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return &ConsulSource{
+		Address:  strings.TrimSuffix(address, "/"),
+		Prefix:   prefix,
+		Token:    os.Getenv("CONSUL_HTTP_TOKEN"),
+		WaitTime: defaultConsulWaitTime,
+		Timeout:  defaultConsulTimeout,
+	}
+}
+
+func (s *ConsulSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	envs, index, err := s.query(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.index = index
+	s.mu.Unlock()
+	return envs, nil
+}
+
+func (s *ConsulSource) Watch(ctx context.Context, changed func()) error {
+	// reset is set once the index went backwards, so that the plain read
+	// starting over counts as a change.
+	reset := false
+	for {
+		s.mu.Lock()
+		index := s.index
+		s.mu.Unlock()
+
+		queryCtx, cancel := context.WithTimeout(ctx, s.waitTime()+s.timeout())
+		_, next, err := s.query(queryCtx, index)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logWatchError("Consul", s.Prefix, err)
+			if !sleepContext(ctx, 5*time.Second) {
+				return nil
+			}
+			continue
+		}
+
+		// Consul may return early with the same index; only a higher index
+		// means the data changed. A lower one means the state was reset,
+		// e.g. by a snapshot restore, and the index starts over from 0, as
+		// Consul's blocking query documentation asks.
+		sent := next
+		switch {
+		case next < index:
+			next, reset = 0, true
+		case next > index && (index > 0 || reset):
+			reset = false
+			changed()
+		}
+		s.mu.Lock()
+		s.index = next
+		s.mu.Unlock()
+
+		// Without an index the next query does not block; pause to avoid
+		// spinning against a server that sends none.
+		if sent == 0 && !sleepContext(ctx, 5*time.Second) {
+			return nil
+		}
+	}
+}
+
+func (s *ConsulSource) query(ctx context.Context, index uint64) (map[string]string, uint64, error) {
+	query := url.Values{"recurse": {"true"}}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%ds", int(s.waitTime().Seconds())))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Address+"/v1/kv/"+strings.TrimPrefix(s.Prefix, "/")+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if s.Token != "" {
+		req.Header.Set("X-Consul-Token", s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading Consul prefix %s: %w", s.Prefix, err)
+	}
+	defer resp.Body.Close()
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	envs := make(map[string]string)
+	if resp.StatusCode == http.StatusNotFound {
+		return envs, next, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("error reading Consul prefix %s: unexpected status %s", s.Prefix, resp.Status)
+	}
+
+	var pairs []struct {
+		Key   string
+		Value string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("error decoding Consul response: %w", err)
+	}
+	for _, pair := range pairs {
+		name := strings.Trim(strings.TrimPrefix(pair.Key, strings.TrimPrefix(s.Prefix, "/")), "/")
+		if name == "" || strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(pair.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid Consul value for %s: %w", pair.Key, err)
+		}
+		envs[normalizePathKey(name)] = string(value)
+	}
+	return envs, next, nil
+}
+
+func (s *ConsulSource) waitTime() time.Duration {
+	return positiveOr(s.WaitTime, defaultConsulWaitTime)
+}
+
+func (s *ConsulSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultConsulTimeout)
+}
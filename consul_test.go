@@ -0,0 +1,147 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConsulLoad(t *testing.T) {
+	value := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "keys under the prefix",
+			status: http.StatusOK,
+			body: fmt.Sprintf(`[{"Key":"myapp/","Value":null},{"Key":"myapp/database/url","Value":%q},{"Key":"myapp/port","Value":%q},{"Key":"myapp/tls/","Value":null}]`,
+				value("postgres://db"), value("8080")),
+			want: map[string]string{"DATABASE_URL": "postgres://db", "PORT": "8080"},
+		},
+		{
+			name:   "missing prefix",
+			status: http.StatusNotFound,
+			want:   map[string]string{},
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			wantErr: "error reading Consul prefix /myapp: unexpected status 500 Internal Server Error",
+		},
+		{
+			name:    "invalid value",
+			status:  http.StatusOK,
+			body:    `[{"Key":"myapp/port","Value":"not base64!"}]`,
+			wantErr: "invalid Consul value for myapp/port: illegal base64 data at input byte 3",
+		},
+		{
+			name:    "invalid body",
+			status:  http.StatusOK,
+			body:    `{`,
+			wantErr: "error decoding Consul response: unexpected EOF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/kv/myapp" || r.URL.Query().Get("recurse") != "true" || r.Header.Get("X-Consul-Token") != "token" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.Header().Set("X-Consul-Index", "7")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			// A struct literal, without the constructor's timeouts.
+			s := &ConsulSource{Address: srv.URL, Prefix: "/myapp", Token: "token"}
+			got, err := s.Load()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, tt.want) {
+				t.Errorf("Load = %v, %v, want %v", got, err, tt.want)
+			}
+			if s.index != 7 {
+				t.Errorf("index = %d, want 7", s.index)
+			}
+		})
+	}
+}
+
+func TestConsulWatch(t *testing.T) {
+	// The index Consul answers each query with: the load, a blocking query
+	// returning early, a change, a reset to a lower index, the plain read
+	// starting over, and a query left blocking.
+	replies := []string{"10", "10", "12", "3", "3"}
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n := len(queries)
+		queries = append(queries, r.URL.Query().Get("index")+"/"+r.URL.Query().Get("wait"))
+		mu.Unlock()
+		if n >= len(replies) {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("X-Consul-Index", replies[n])
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	s := &ConsulSource{Address: srv.URL, Prefix: "myapp", WaitTime: time.Second}
+	if _, err := s.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() { done <- s.Watch(ctx, func() { changes <- struct{}{} }) }()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(queries)
+		mu.Unlock()
+		if n > len(replies) {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("Watch made %d queries, want %d", n, len(replies)+1)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	want := []string{"/", "10/1s", "10/1s", "12/1s", "/", "3/1s"}
+	if !slices.Equal(queries, want) {
+		t.Errorf("queries = %s, want %s", strings.Join(queries, " "), strings.Join(want, " "))
+	}
+	if got := len(changes); got != 2 {
+		t.Errorf("changed called %d times, want 2: after the new index and after the reset", got)
+	}
+}
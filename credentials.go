@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewCredentialsSource reads systemd credentials (LoadCredential=,
+// SetCredentialEncrypted=, ...) from dir, each file name being the key and
+// its content the value. An empty dir means $CREDENTIALS_DIRECTORY.
+// NewConfig already applies $CREDENTIALS_DIRECTORY on top of every other
+// source when it is set.
+func NewCredentialsSource(dir string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		if dir == "" {
+			dir = os.Getenv("CREDENTIALS_DIRECTORY")
+		}
+		return loadCredentials(dir)
+	})
+}
+
+func loadCredentials(dir string) (map[string]string, error) {
+	envs := make(map[string]string)
+	if dir == "" {
+		return envs, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return envs, nil
+		}
+		return nil, fmt.Errorf("error reading credentials directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading credential %s: %w", entry.Name(), err)
+		}
+		envs[entry.Name()] = strings.TrimSuffix(string(data), "\n")
+	}
+	return envs, nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+func WithCUEFile(file string) Option {
+	return withFile("CUE", file, func(data []byte) (any, error) {
+		return decodeCUE(file, data)
+	})
+}
+
+func decodeCUEData(data []byte) (any, error) {
+	return decodeCUE("", data)
+}
+
+func decodeCUE(file string, data []byte) (any, error) {
+	value := cuecontext.New().CompileBytes(data, cue.Filename(file))
+	if err := value.Err(); err != nil {
+		return nil, err
+	}
+	if err := value.Validate(cue.Concrete(true), cue.Final()); err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := value.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Setter is implemented by types that parse their own configuration value.
+// Unmarshal and struct binding call Set with the raw value in preference to
+// the built-in decoding, so a type can validate it as it sees fit.
+type Setter interface {
+	Set(raw string) error
+}
+
+var (
+	setterType            = reflect.TypeOf((*Setter)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	durationType          = reflect.TypeOf(time.Duration(0))
+	urlType               = reflect.TypeOf(url.URL{})
+)
+
+// Unmarshal decodes the value of key into v, which must be a pointer.
+// Types implementing Setter, encoding.TextUnmarshaler or
+// encoding.BinaryUnmarshaler decode themselves; strings, bools, numbers,
+// durations, URLs, slices (split like GetStrings, base64 for []byte) and maps
+// (pairs like GetStringMap) are handled directly.
+func (c *Config) Unmarshal(key string, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("cannot unmarshal %s into non-pointer %T", key, v)
+	}
+	value := c.Get(key)
+	if value == "" {
+		return fmt.Errorf("%s is not set", key)
+	}
+	if err := c.decodeValue(value, rv.Elem()); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
+
+// WithDecoder registers a conversion for values of type T used by
+// Unmarshal and struct binding, e.g. to build a driver config from a DSN or
+// read a []byte as hex. It takes precedence over the built-in decoding.
+func WithDecoder[T any](decode func(string) (T, error)) Option {
+	return func(c *Config) {
+		if c.decoders == nil {
+			c.decoders = make(map[reflect.Type]func(string) (any, error))
+		}
+		c.decoders[reflect.TypeOf((*T)(nil)).Elem()] = func(s string) (any, error) {
+			return decode(s)
+		}
+	}
+}
+
+func (c *Config) decodeValue(s string, v reflect.Value) error {
+	if decode, ok := c.decoders[v.Type()]; ok {
+		value, err := decode(s)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return c.decodeValue(s, v.Elem())
+	}
+
+	if v.CanAddr() {
+		switch {
+		case v.Addr().Type().Implements(setterType):
+			return v.Addr().Interface().(Setter).Set(s)
+		case c.envconfig && v.Addr().Type().Implements(envconfigDecoderType):
+			return v.Addr().Interface().(interface{ Decode(string) error }).Decode(s)
+		case v.Addr().Type().Implements(textUnmarshalerType):
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		case v.Type() == urlType:
+			u, err := parseURL(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(*u))
+			return nil
+		case v.Addr().Type().Implements(binaryUnmarshalerType):
+			return v.Addr().Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary([]byte(s))
+		}
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data, err := decodeBase64(s)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(data)
+			return nil
+		}
+		items := c.splitList(s)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := c.decodeValue(item, slice.Index(i)); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		v.Set(slice)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map key type %s", v.Type().Key())
+		}
+		pairs, err := mapParser(c, parseString)(s)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(pairs))
+		for name, item := range pairs {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := c.decodeValue(item, elem); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+// decodeHex implements encoding.BinaryUnmarshaler, used when a type has no
+// Setter or TextUnmarshaler.
+type decodeHex []byte
+
+func (h *decodeHex) UnmarshalBinary(data []byte) error {
+	*h = append([]byte("0x"), data...)
+	return nil
+}
+
+func TestUnmarshal(t *testing.T) {
+	values := map[string]string{
+		"IP":      "10.0.0.1",
+		"HEX":     "ff",
+		"ORIGINS": "https://a; https://b",
+		"KEY":     "not base64!",
+		"CHAN":    "x",
+		"EMPTY":   "",
+	}
+	c, err := LoadSnapshot(WithEnvFile(emptyEnvFile(t)), WithOverrides(values), WithListSeparator(";"))
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		into    any
+		want    any
+		wantErr string
+	}{
+		{"TextUnmarshaler", "IP", new(net.IP), ptr(net.ParseIP("10.0.0.1")), ""},
+		{"BinaryUnmarshaler", "HEX", new(decodeHex), ptr(decodeHex("0xff")), ""},
+		{"list separator", "ORIGINS", new([]string), &[]string{"https://a", "https://b"}, ""},
+		{"pointer allocated", "IP", new(*net.IP), ptr(ptr(net.ParseIP("10.0.0.1"))), ""},
+		{"invalid base64", "KEY", new([]byte), nil, "invalid value for KEY: value is not valid base64"},
+		{"unsupported type", "CHAN", new(chan int), nil, "invalid value for CHAN: unsupported type chan int"},
+		{"unsupported map key", "ORIGINS", new(map[int]string), nil, "invalid value for ORIGINS: unsupported map key type int"},
+		{"not set", "MISSING", new(string), nil, "MISSING is not set"},
+		{"empty", "EMPTY", new(string), nil, "EMPTY is not set"},
+		{"not a pointer", "IP", "", nil, "cannot unmarshal IP into non-pointer string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.Unmarshal(tt.key, tt.into)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Unmarshal error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(tt.into, tt.want) {
+				t.Errorf("Unmarshal = %v, want %v", tt.into, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
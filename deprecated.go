@@ -0,0 +1,51 @@
+package config
+
+import "fmt"
+
+// Deprecation is the warning raised when a deprecated key is set. It is
+// kept in Warnings, wrapped in a Warning, for callers reporting renames to
+// their own telemetry.
+type Deprecation struct {
+	Key         string
+	Replacement string
+	// RemovedIn is the version that stops reading Key, e.g. "v3.0".
+	RemovedIn string
+}
+
+func (d *Deprecation) Error() string {
+	msg := d.Key + " is deprecated"
+	if d.RemovedIn != "" {
+		msg += " and will be removed in " + d.RemovedIn
+	}
+	return fmt.Sprintf("%s, use %s instead", msg, d.Replacement)
+}
+
+type deprecatedKey struct {
+	old, key, removedIn string
+}
+
+// WithDeprecatedKey keeps a renamed key working: while key is not set, the
+// value of old is used for it, with a warning naming the version removing
+// old, e.g. WithDeprecatedKey("OLD_DB_URL", "DATABASE_URL", "v3.0").
+func WithDeprecatedKey(old, key, removedIn string) Option {
+	return func(c *Config) {
+		c.deprecated = append(c.deprecated, deprecatedKey{old: old, key: key, removedIn: removedIn})
+		c.knownKeys = append(c.knownKeys, old)
+	}
+}
+
+// applyDeprecated copies the values of deprecated keys that are set onto
+// their replacements in envs.
+func (c *Config) applyDeprecated(envs map[string]string, sourced map[string]bool) {
+	for _, d := range c.deprecated {
+		value := getEnvWithFallback(c.environ(), envs, d.old, "")
+		if value == "" {
+			continue
+		}
+		c.collectWarnings(&Warning{Err: &Deprecation{Key: d.old, Replacement: d.key, RemovedIn: d.removedIn}})
+		if getEnvWithFallback(c.environ(), envs, d.key, "") == "" {
+			envs[d.key] = value
+			sourced[d.key] = true
+		}
+	}
+}
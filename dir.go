@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func WithConfDir(dir, pattern string) Option {
+	return func(c *Config) {
+		if dir != "" {
+			c.sources = append(c.sources, newDirSource(dir, pattern, c.overlay))
+		}
+	}
+}
+
+// NewDirSource loads every file in dir matching pattern (all files when
+// pattern is empty) in lexical order, later files overriding earlier ones.
+// The format is picked from the file extension.
+func NewDirSource(dir, pattern string) Source {
+	return newDirSource(dir, pattern, defaultOverlay)
+}
+
+func newDirSource(dir, pattern string, overlay overlayFunc) Source {
+	if pattern == "" {
+		pattern = "*"
+	}
+	return SourceFunc(func() (map[string]string, error) {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			log.Printf("Warning: config directory not found at %s, skipping", dir)
+			return make(map[string]string), nil
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for config directory %s: %w", dir, err)
+		}
+
+		envs := make(map[string]string)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("error reading config directory: %w", err)
+			}
+			if info.IsDir() {
+				continue
+			}
+
+			values, err := loadFileByExt(file, overlay)
+			if err != nil {
+				return nil, err
+			}
+			overlay(envs, values)
+		}
+		return envs, nil
+	})
+}
+
+func loadFileByExt(file string, overlay overlayFunc) (map[string]string, error) {
+	f, err := lookupFormat(formatForFile(file))
+	if err != nil {
+		return nil, err
+	}
+	return newFileSource(f.kind, file, f.decode, overlay).Load()
+}
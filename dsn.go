@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DSN is a parsed database connection string. URLs such as
+// postgres://app:pw@db:5432/orders?sslmode=require, the go-sql-driver form
+// app:pw@tcp(db:3306)/orders and sqlite:///var/lib/app.db are accepted.
+// Printing a DSN masks the password only, so the rest stays useful in logs.
+type DSN struct {
+	// Scheme is "postgres", "mysql" or "sqlite".
+	Scheme   string
+	User     string
+	Password Secret
+	// Host and Port are empty and 0 for sqlite; Host is the socket path and
+	// Port 0 for a mysql unix socket. A missing port defaults to 5432 for
+	// postgres and 3306 for mysql.
+	Host string
+	Port int
+	// Database is the database name, or the file path for sqlite.
+	Database string
+	Params   url.Values
+}
+
+var dsnPorts = map[string]int{"postgres": 5432, "mysql": 3306}
+
+// String returns the DSN as a URL with the password masked.
+func (d DSN) String() string {
+	u := url.URL{Scheme: d.Scheme, RawQuery: d.Params.Encode()}
+	if d.Scheme == "sqlite" {
+		u.Path = d.Database
+		if !strings.HasPrefix(u.Path, "/") {
+			u.Opaque = d.Database
+		}
+		return u.String()
+	}
+	switch {
+	case d.Password != "":
+		u.User = url.UserPassword(d.User, "xxxxx")
+	case d.User != "":
+		u.User = url.User(d.User)
+	}
+	u.Host = d.Address()
+	u.Path = "/" + d.Database
+	return u.String()
+}
+
+// Address returns host:port, as needed by most pool configurations, or the
+// socket path for a mysql unix socket.
+func (d DSN) Address() string {
+	if d.Port == 0 {
+		return d.Host
+	}
+	return net.JoinHostPort(d.Host, strconv.Itoa(d.Port))
+}
+
+// UnmarshalText lets bound struct fields of type DSN parse themselves.
+func (d *DSN) UnmarshalText(text []byte) error {
+	parsed, err := parseDSN(string(text))
+	if err != nil {
+		return err
+	}
+	*d = *parsed
+	return nil
+}
+
+// WithDSNKeys declares keys holding database connection strings, checked by
+// NewConfig like DATABASE_URL.
+func WithDSNKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parseDSN(value)
+		return err
+	})
+}
+
+func (c *Config) GetDSN(key string) (*DSN, error) {
+	return getValue(c, key, parseDSN)
+}
+
+func parseDSN(s string) (*DSN, error) {
+	if !strings.Contains(s, "://") && !strings.HasPrefix(s, "sqlite") {
+		if i := strings.LastIndex(s, "@"); i >= 0 && strings.Contains(s[i:], "(") {
+			return parseMySQLDSN(s)
+		}
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection string: %w", stripURLError(err))
+	}
+	d := &DSN{Scheme: u.Scheme, Params: u.Query()}
+	switch d.Scheme {
+	case "postgresql":
+		d.Scheme = "postgres"
+	case "sqlite3":
+		d.Scheme = "sqlite"
+	}
+
+	switch d.Scheme {
+	case "sqlite":
+		d.Database = u.Opaque
+		if d.Database == "" {
+			d.Database = u.Host + u.Path
+		}
+		if d.Database == "" {
+			return nil, fmt.Errorf("%q has no database file", u.Redacted())
+		}
+		return d, nil
+	case "postgres", "mysql":
+	default:
+		return nil, fmt.Errorf("unsupported database scheme %q, use postgres, mysql or sqlite", u.Scheme)
+	}
+
+	if u.User != nil {
+		d.User = u.User.Username()
+		password, _ := u.User.Password()
+		d.Password = Secret(password)
+	}
+	d.Host = u.Hostname()
+	if d.Host == "" {
+		return nil, fmt.Errorf("%q has no host", u.Redacted())
+	}
+	d.Port = dsnPorts[d.Scheme]
+	if port := u.Port(); port != "" {
+		if d.Port, err = parsePort(port); err != nil {
+			return nil, err
+		}
+	}
+	d.Database = strings.TrimPrefix(u.Path, "/")
+	return d, nil
+}
+
+// parseMySQLDSN parses the go-sql-driver/mysql form
+// user:password@protocol(address)/dbname?params.
+func parseMySQLDSN(s string) (*DSN, error) {
+	i := strings.LastIndex(s, "@")
+	d := &DSN{Scheme: "mysql", Port: dsnPorts["mysql"]}
+	user, password, _ := strings.Cut(s[:i], ":")
+	d.User, d.Password = user, Secret(password)
+
+	rest := s[i+1:]
+	open, end := strings.Index(rest, "("), strings.Index(rest, ")")
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("invalid mysql address in %q", redactMySQLDSN(s))
+	}
+	address := rest[open+1 : end]
+	if protocol := rest[:open]; protocol == "unix" {
+		d.Host, d.Port = address, 0
+	} else if host, port, err := net.SplitHostPort(address); err == nil {
+		d.Host = host
+		if d.Port, err = parsePort(port); err != nil {
+			return nil, err
+		}
+	} else {
+		d.Host = address
+	}
+
+	path, query, _ := strings.Cut(rest[end+1:], "?")
+	d.Database = strings.TrimPrefix(path, "/")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mysql parameters: %w", err)
+	}
+	d.Params = params
+	return d, nil
+}
+
+func redactMySQLDSN(s string) string {
+	i := strings.LastIndex(s, "@")
+	if user, _, ok := strings.Cut(s[:i], ":"); ok {
+		return user + ":xxxxx" + s[i:]
+	}
+	return s
+}
+
+// redactCredentials masks the password of a URL or mysql DSN, for quoting a
+// value that failed validation.
+func redactCredentials(s string) string {
+	scheme, rest, isURL := strings.Cut(s, "://")
+	if !isURL {
+		if strings.Contains(s, "@") {
+			return redactMySQLDSN(s)
+		}
+		return s
+	}
+	if u, err := url.Parse(s); err == nil {
+		return u.Redacted()
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		return scheme + "://" + redactMySQLDSN(rest)
+	}
+	return s
+}
+
+// stripURLError drops the *url.Error wrapper, whose message repeats the
+// full input including any password.
+func stripURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithEnumKey declares a key that only accepts one of the allowed values,
+// checked by NewConfig.
+func WithEnumKey(key string, allowed ...string) Option {
+	return withChecks([]string{key}, func(c *Config, value string) error {
+		_, err := parseEnum(value, allowed)
+		return err
+	})
+}
+
+// GetEnum returns the value of key if it matches one of the allowed values,
+// ignoring case. The allowed spelling is returned.
+func (c *Config) GetEnum(key string, allowed ...string) (string, error) {
+	return getValue(c, key, func(s string) (string, error) { return parseEnum(s, allowed) })
+}
+
+func (c *Config) GetEnumWithFallback(key, fallback string, allowed ...string) string {
+	return getValueWithFallback(c, key, "enum", fallback, func(s string) (string, error) { return parseEnum(s, allowed) })
+}
+
+func parseEnum(s string, allowed []string) (string, error) {
+	for _, value := range allowed {
+		if strings.EqualFold(s, value) {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("%q is not one of %s", s, strings.Join(allowed, ", "))
+}
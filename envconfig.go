@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WithEnvconfigTags binds structs written for kelseyhightower/envconfig
+// without edits: fields without an env tag bind under their envconfig tag
+// or field name, split_words:"true" turns DatabaseURL into DATABASE_URL,
+// ignored:"true" skips a field, and keys are prefixed like
+// envconfig.Process(prefix, &spec), falling back to the unprefixed
+// envconfig tag. Pointers to structs are always allocated and types with a
+// Decode(string) error method decode themselves, as in envconfig.
+func WithEnvconfigTags(prefix string) Option {
+	return func(c *Config) {
+		c.envconfig = true
+		c.envconfigPrefix = prefix
+	}
+}
+
+var envconfigDecoderType = reflect.TypeOf((*interface{ Decode(string) error })(nil)).Elem()
+
+var (
+	envconfigWords    = regexp.MustCompile(`([^A-Z]+|[A-Z]+[^A-Z]+|[A-Z]+)`)
+	envconfigAcronyms = regexp.MustCompile(`([A-Z]+)([A-Z][^A-Z]+)`)
+)
+
+// bindPrefix is the prefix of the top-level keys of a bound struct.
+func (c *Config) bindPrefix() string {
+	if !c.envconfig || c.envconfigPrefix == "" {
+		return ""
+	}
+	return strings.ToUpper(c.envconfigPrefix) + c.keySep()
+}
+
+// envconfigKey returns the key envconfig derives for an untagged field and
+// its unprefixed alternative, or false if the field is ignored.
+func envconfigKey(field reflect.StructField) (string, string, bool) {
+	if ignored, _ := strconv.ParseBool(field.Tag.Get("ignored")); ignored {
+		return "", "", false
+	}
+	alt := strings.ToUpper(field.Tag.Get("envconfig"))
+	if alt != "" {
+		return alt, alt, true
+	}
+
+	key := field.Name
+	if split, _ := strconv.ParseBool(field.Tag.Get("split_words")); split {
+		var words []string
+		for _, word := range envconfigWords.FindAllString(field.Name, -1) {
+			if m := envconfigAcronyms.FindStringSubmatch(word); m != nil {
+				words = append(words, m[1], m[2])
+			} else {
+				words = append(words, word)
+			}
+		}
+		key = strings.Join(words, "_")
+	}
+	return strings.ToUpper(key), "", true
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EtcdSource loads every key under Prefix through the etcd v3 JSON gateway.
+// The prefix is stripped and the remainder mapped like a nested file key,
+// so /myapp/database/url becomes DATABASE_URL.
+type EtcdSource struct {
+	Endpoint string
+	Prefix   string
+	Username string
+	Password string
+	// Timeout defaults to 5s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	mu       sync.Mutex
+	token    string
+	revision int64
+}
+
+const defaultEtcdTimeout = 5 * time.Second
+
+func NewEtcdSource(endpoint, prefix string) *EtcdSource {
+	return &EtcdSource{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Prefix:   prefix,
+		Timeout:  defaultEtcdTimeout,
+	}
+}
+
+type etcdKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type etcdHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+func (s *EtcdSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	var resp struct {
+		Header etcdHeader     `json:"header"`
+		Kvs    []etcdKeyValue `json:"kvs"`
+	}
+	body := map[string]string{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.Prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(s.Prefix)),
+	}
+	if err := s.post(ctx, "/v3/kv/range", body, &resp); err != nil {
+		return nil, fmt.Errorf("error reading etcd prefix %s: %w", s.Prefix, err)
+	}
+
+	envs := make(map[string]string, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		key, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid etcd key: %w", err)
+		}
+		value, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid etcd value for %s: %w", key, err)
+		}
+		envs[normalizePathKey(strings.TrimLeft(strings.TrimPrefix(string(key), s.Prefix), "/"))] = string(value)
+	}
+
+	s.mu.Lock()
+	s.revision = resp.Header.Revision
+	s.mu.Unlock()
+	return envs, nil
+}
+
+func (s *EtcdSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		err := s.watchOnce(ctx, changed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logWatchError("etcd", s.Prefix, err)
+		}
+		if !sleepContext(ctx, 2*time.Second) {
+			return nil
+		}
+	}
+}
+
+func (s *EtcdSource) watchOnce(ctx context.Context, changed func()) error {
+	s.mu.Lock()
+	revision := s.revision
+	s.mu.Unlock()
+
+	create := map[string]any{
+		"key":       base64.StdEncoding.EncodeToString([]byte(s.Prefix)),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(s.Prefix)),
+	}
+	if revision > 0 {
+		create["start_revision"] = revision + 1
+	}
+	resp, err := s.do(ctx, "/v3/watch", map[string]any{"create_request": create})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg struct {
+			Result struct {
+				Header etcdHeader        `json:"header"`
+				Events []json.RawMessage `json:"events"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("invalid watch response: %w", err)
+		}
+		if len(msg.Result.Events) == 0 {
+			continue
+		}
+		s.mu.Lock()
+		s.revision = msg.Result.Header.Revision
+		s.mu.Unlock()
+		changed()
+	}
+	return scanner.Err()
+}
+
+func (s *EtcdSource) post(ctx context.Context, path string, body, out any) error {
+	resp, err := s.do(ctx, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (s *EtcdSource) do(ctx context.Context, path string, body any) (*http.Response, error) {
+	token, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, path)
+	}
+	return resp, nil
+}
+
+func (s *EtcdSource) authenticate(ctx context.Context) (string, error) {
+	if s.Username == "" {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" {
+		return s.token, nil
+	}
+
+	payload, err := json.Marshal(map[string]string{"name": s.Username, "password": s.Password})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Endpoint+"/v3/auth/authenticate", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error authenticating to etcd: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error authenticating to etcd: unexpected status %s", resp.Status)
+	}
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return "", fmt.Errorf("error decoding etcd auth response: %w", err)
+	}
+	s.token = auth.Token
+	return s.token, nil
+}
+
+func (s *EtcdSource) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+func (s *EtcdSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultEtcdTimeout)
+}
+
+// prefixRangeEnd returns the etcd range end that selects every key starting
+// with prefix.
+func prefixRangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
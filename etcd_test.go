@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPrefixRangeEnd(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"/myapp/", "/myapp0"},
+		{"/myapp", "/myapq"},
+		{"a\xff", "b"},
+		{"a\xff\xff", "b"},
+		{"\xff\xff", "\x00"},
+		{"", "\x00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := string(prefixRangeEnd(tt.prefix)); got != tt.want {
+				t.Errorf("prefixRangeEnd(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEtcdLoad(t *testing.T) {
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	kvs := fmt.Sprintf(`{"header":{"revision":"42"},"kvs":[{"key":%q,"value":%q},{"key":%q,"value":%q}]}`,
+		b64("/myapp/database/url"), b64("postgres://db"), b64("/myapp/port"), b64("8080"))
+
+	tests := []struct {
+		name     string
+		username string
+		auth     int
+		reply    string
+		status   int
+		want     map[string]string
+		wantErr  string
+	}{
+		{name: "range", reply: kvs, status: http.StatusOK, want: map[string]string{"DATABASE_URL": "postgres://db", "PORT": "8080"}},
+		{name: "authenticated", username: "app", auth: http.StatusOK, reply: kvs, status: http.StatusOK, want: map[string]string{"DATABASE_URL": "postgres://db", "PORT": "8080"}},
+		{name: "empty", reply: `{"header":{"revision":"42"}}`, status: http.StatusOK, want: map[string]string{}},
+		{name: "rejected credentials", username: "app", auth: http.StatusUnauthorized, wantErr: "error reading etcd prefix /myapp/: error authenticating to etcd: unexpected status 401 Unauthorized"},
+		{name: "server error", status: http.StatusServiceUnavailable, wantErr: "error reading etcd prefix /myapp/: unexpected status 503 Service Unavailable from /v3/kv/range"},
+		{name: "invalid key", reply: `{"kvs":[{"key":"!","value":""}]}`, status: http.StatusOK, wantErr: "invalid etcd key: illegal base64 data at input byte 0"},
+		{name: "invalid value", reply: fmt.Sprintf(`{"kvs":[{"key":%q,"value":"!"}]}`, b64("/myapp/port")), status: http.StatusOK, wantErr: "invalid etcd value for /myapp/port: illegal base64 data at input byte 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v3/auth/authenticate":
+					var creds map[string]string
+					json.NewDecoder(r.Body).Decode(&creds)
+					if creds["name"] != "app" || creds["password"] != "secret" {
+						t.Errorf("credentials = %v", creds)
+					}
+					w.WriteHeader(tt.auth)
+					w.Write([]byte(`{"token":"etcd-token"}`))
+				case "/v3/kv/range":
+					var body map[string]string
+					json.NewDecoder(r.Body).Decode(&body)
+					if body["key"] != b64("/myapp/") || body["range_end"] != b64("/myapp0") {
+						t.Errorf("range request = %v", body)
+					}
+					want := ""
+					if tt.username != "" {
+						want = "etcd-token"
+					}
+					if got := r.Header.Get("Authorization"); got != want {
+						t.Errorf("Authorization = %q, want %q", got, want)
+					}
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.reply))
+				default:
+					t.Errorf("unexpected request %s", r.URL)
+				}
+			}))
+			defer srv.Close()
+
+			// A struct literal, without the constructor's timeout.
+			s := &EtcdSource{Endpoint: srv.URL, Prefix: "/myapp/", Username: tt.username, Password: "secret"}
+			got, err := s.Load()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, tt.want) {
+				t.Errorf("Load = %v, %v, want %v", got, err, tt.want)
+			}
+			if s.revision != 42 {
+				t.Errorf("revision = %d, want 42", s.revision)
+			}
+		})
+	}
+}
+
+func TestEtcdWatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			CreateRequest struct {
+				Key           string `json:"key"`
+				RangeEnd      string `json:"range_end"`
+				StartRevision int64  `json:"start_revision"`
+			} `json:"create_request"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.CreateRequest.StartRevision != 43 || body.CreateRequest.RangeEnd != base64.StdEncoding.EncodeToString([]byte("/myapp0")) {
+			t.Errorf("watch request = %+v", body.CreateRequest)
+		}
+		// The created notice, then one event.
+		fmt.Fprintln(w, `{"result":{"header":{"revision":"42"},"created":true}}`)
+		fmt.Fprintln(w, `{"result":{"header":{"revision":"45"},"events":[{"kv":{}}]}}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	s := &EtcdSource{Endpoint: srv.URL, Prefix: "/myapp/", revision: 42}
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() { done <- s.Watch(ctx, func() { changes <- struct{}{} }) }()
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not report the event")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.revision != 45 {
+		t.Errorf("revision = %d, want 45", s.revision)
+	}
+}
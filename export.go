@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+const maskedValue = "********"
+
+// WithSecretKeys marks keys whose values Export masks, in addition to the
+// built-in Secret fields such as DATABASE_URL and every key read from a
+// KEY_FILE file.
+func WithSecretKeys(keys ...string) Option {
+	return func(c *Config) {
+		c.secretKeys = append(c.secretKeys, keys...)
+	}
+}
+
+// Export writes every resolved key in dotenv format: the built-in settings
+// and the keys set by flags, files, sources, overrides and defaults. The
+// OS environment is included as narrowed by WithEnvPrefix; the unnarrowed
+// process environment, with PATH and the like, is left out. KEY_FILE
+// variables are written as KEY with the file's contents.
+func (c *Config) Export(w io.Writer, maskSecrets bool) error {
+	envs := c.envMap()
+	if maskSecrets {
+		secret := c.secretKeySet()
+		for key := range envs {
+			if secret[key] && envs[key] != "" {
+				envs[key] = maskedValue
+			}
+		}
+	}
+
+	content, err := godotenv.Marshal(envs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if _, err := io.WriteString(w, content+"\n"); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) WriteEnvFile(path string, maskSecrets bool) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create env file: %w", err)
+	}
+	if err := c.Export(f, maskSecrets); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (c *Config) envMap() map[string]string {
+	envs := make(map[string]string)
+	for _, name := range c.exportNames() {
+		key := name
+		if base, ok := strings.CutSuffix(name, "_FILE"); ok && base != "" {
+			key = base
+		}
+		if value := c.Get(key); value != "" {
+			envs[key] = value
+		}
+	}
+	maps.Copy(envs, map[string]string{
+		"DATABASE_URL":     c.DatabaseURL.Reveal(),
+		"AUTH_SERVICE_URL": c.AuthServiceURL,
+		"DEBUG":            strconv.FormatBool(c.Debug),
+		"PORT":             c.Port,
+		"HTTP_TIMEOUT":     c.HTTPTimeout.String(),
+		"SHUTDOWN_GRACE":   c.ShutdownGrace.String(),
+		"LOG_FORMAT":       c.LogFormat,
+		"LOG_LEVEL":        c.LogLevel.String(),
+	})
+	return envs
+}
+
+// exportNames lists the keys Export writes besides the built-in ones.
+// Views such as Sub hold no process environment and export all of envs.
+func (c *Config) exportNames() []string {
+	if c.resolved == nil {
+		return nil
+	}
+	envs, defaults := c.resolvedEnvs()
+	var names []string
+	if c.resolved.sourced != nil {
+		for name := range c.resolved.sourced {
+			names = append(names, name)
+		}
+	} else {
+		for name := range envs {
+			names = append(names, name)
+		}
+	}
+	for name := range defaults {
+		names = append(names, name)
+	}
+	if e := c.environ(); e != nil && e.vars != nil {
+		names = append(names, e.names()...)
+	}
+	return names
+}
+
+// secretKeySet returns the keys Export masks.
+func (c *Config) secretKeySet() map[string]bool {
+	secret := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Type == reflect.TypeOf(Secret("")) {
+			key, _ := parseEnvTag(field.Tag.Get("env"))
+			secret[key] = true
+		}
+	}
+	for _, key := range c.secretKeys {
+		secret[key] = true
+	}
+	for _, name := range c.exportNames() {
+		if base, ok := strings.CutSuffix(name, "_FILE"); ok && base != "" {
+			secret[base] = true
+		}
+	}
+	return secret
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("WORKERS=4\nGREETING=\"hello world\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EXPORT_TEST_UNRELATED", "process")
+	t.Setenv("MYAPP_REGION", "eu-west-1")
+
+	opts := []Option{
+		WithEnvFile(envFile),
+		WithEnvPrefix("MYAPP_"),
+		WithDatabaseURL("postgres://app:pw@db/app"),
+		WithAuthServiceURL("http://auth"),
+		WithValue("CACHE_TTL", "5m"),
+		WithOverrides(map[string]string{"API_TOKEN_FILE": token, "API_KEY": "key-123", "PORT": "9090"}),
+		WithSecretKeys("API_KEY"),
+	}
+	c, err := NewConfig(opts...)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config *Config
+		mask   bool
+		want   map[string]string
+	}{
+		{
+			name:   "unmasked",
+			config: c,
+			want: map[string]string{
+				"WORKERS":      "4",
+				"GREETING":     "hello world",
+				"REGION":       "eu-west-1",
+				"CACHE_TTL":    "5m",
+				"API_TOKEN":    "s3cret",
+				"API_KEY":      "key-123",
+				"PORT":         "9090",
+				"DATABASE_URL": "postgres://app:pw@db/app",
+				"DEBUG":        "false",
+			},
+		},
+		{
+			name:   "masked",
+			config: c,
+			mask:   true,
+			want: map[string]string{
+				"WORKERS":      "4",
+				"API_TOKEN":    maskedValue,
+				"API_KEY":      maskedValue,
+				"DATABASE_URL": maskedValue,
+			},
+		},
+		{
+			name:   "snapshot",
+			config: c.Snapshot(),
+			want:   map[string]string{"WORKERS": "4", "REGION": "eu-west-1", "API_TOKEN": "s3cret"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := tt.config.Export(&out, tt.mask); err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			got, err := godotenv.Unmarshal(out.String())
+			if err != nil {
+				t.Fatalf("exported file does not parse: %v\n%s", err, out.String())
+			}
+			for key, want := range tt.want {
+				if got[key] != want {
+					t.Errorf("%s = %q, want %q", key, got[key], want)
+				}
+			}
+			for _, key := range []string{"EXPORT_TEST_UNRELATED", "MYAPP_REGION", "API_TOKEN_FILE", "PATH"} {
+				if value, ok := got[key]; ok {
+					t.Errorf("exported %s=%q, want it left out", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	t.Setenv("EXPORT_TEST_UNRELATED", "process")
+	c, err := NewConfig(
+		WithEnvFile(emptyEnvFile(t)),
+		WithOverrides(map[string]string{
+			"DATABASE_URL":     "postgres://db/app",
+			"AUTH_SERVICE_URL": "http://auth",
+			"PORT":             "8081",
+			"LOG_LEVEL":        "warn",
+			"UPSTREAM_0_URL":   "http://a",
+			"ALLOWED_ORIGINS":  "https://a,https://b",
+			"MESSAGE":          "multi\nline \"quoted\" $HOME",
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "booted.env")
+	if err := c.WriteEnvFile(file, false); err != nil {
+		t.Fatalf("WriteEnvFile: %v", err)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("WriteEnvFile mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	booted, err := NewConfig(WithEnvFile(file))
+	if err != nil {
+		t.Fatalf("NewConfig from the exported file: %v", err)
+	}
+	want := c.envMap()
+	got := booted.envMap()
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q after the round trip, want %q", key, got[key], value)
+		}
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			t.Errorf("%s appeared after the round trip", key)
+		}
+	}
+}
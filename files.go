@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+type decodeFunc func(data []byte) (any, error)
+
+func newFileSource(kind, file string, decode decodeFunc, overlay overlayFunc) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Printf("Warning: %s file not found at %s, skipping", kind, file)
+				return make(map[string]string), nil
+			}
+			return nil, fmt.Errorf("error reading %s file: %w", kind, err)
+		}
+
+		return decodeFile(kind, file, data, decode, nil, overlay)
+	})
+}
+
+func withFile(kind, file string, decode decodeFunc) Option {
+	return func(c *Config) {
+		if file != "" {
+			c.sources = append(c.sources, newFileSource(kind, file, decode, c.overlay))
+		}
+	}
+}
+
+// newReaderSource consumes r on the first Load and serves the decoded
+// values from memory afterwards, since a reader can only be read once.
+func newReaderSource(kind string, r io.Reader, decode decodeFunc) Source {
+	var once sync.Once
+	var envs map[string]string
+	var loadErr error
+
+	return SourceFunc(func() (map[string]string, error) {
+		once.Do(func() {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				loadErr = fmt.Errorf("error reading %s input: %w", kind, err)
+				return
+			}
+
+			doc, err := decode(data)
+			if err != nil {
+				loadErr = fmt.Errorf("error parsing %s input: %w", kind, err)
+				return
+			}
+
+			envs = toEnvs(doc)
+		})
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return copyEnvs(envs), nil
+	})
+}
+
+func withReader(kind string, r io.Reader, decode decodeFunc) Option {
+	if r == nil {
+		return func(c *Config) {}
+	}
+	src := newReaderSource(kind, r, decode)
+	return func(c *Config) {
+		c.sources = append(c.sources, src)
+	}
+}
+
+// toEnvs flattens a decoded document; flat formats such as dotenv decode
+// straight to a map[string]string and keep their keys verbatim.
+func toEnvs(doc any) map[string]string {
+	if flat, ok := doc.(map[string]string); ok {
+		return flat
+	}
+	envs := make(map[string]string)
+	flattenInto(envs, "", doc)
+	return envs
+}
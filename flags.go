@@ -0,0 +1,32 @@
+package config
+
+import "flag"
+
+// WithFlags adds the flags set on the command line as the highest layer,
+// so --port=9000 --debug overrides the environment and every file. Flag
+// names map to keys like structured files, so --http-timeout sets
+// HTTP_TIMEOUT; flags left at their default do not count. Parse fs before
+// loading:
+//
+//	flag.String("port", "", "listen port")
+//	flag.Parse()
+//	cfg, err := config.NewConfig(config.WithFlags(flag.CommandLine))
+func WithFlags(fs *flag.FlagSet) Option {
+	return func(c *Config) {
+		if fs != nil {
+			c.flags = append(c.flags, fs)
+		}
+	}
+}
+
+// flagValues returns the values of the flags set on the command line,
+// later flag sets overriding earlier ones.
+func (c *Config) flagValues() map[string]string {
+	values := make(map[string]string)
+	for _, fs := range c.flags {
+		fs.Visit(func(f *flag.Flag) {
+			values[normalizeKey(f.Name)] = f.Value.String()
+		})
+	}
+	return values
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// flattenInto maps a decoded structured document onto env-style keys:
+// nested keys are joined with "_" and upper-cased (database.url becomes
+// DATABASE_URL), scalar lists are comma-joined and lists of tables are
+// indexed (UPSTREAM_0_URL).
+func flattenInto(envs map[string]string, prefix string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			flattenInto(envs, joinKey(prefix, key), child)
+		}
+	case map[any]any:
+		for key, child := range v {
+			flattenInto(envs, joinKey(prefix, fmt.Sprint(key)), child)
+		}
+	case []map[string]any:
+		for i, item := range v {
+			flattenInto(envs, joinKey(prefix, strconv.Itoa(i)), item)
+		}
+	case []any:
+		if isScalarList(v) {
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = scalarString(item)
+			}
+			envs[prefix] = strings.Join(items, ",")
+			return
+		}
+		for i, item := range v {
+			flattenInto(envs, joinKey(prefix, strconv.Itoa(i)), item)
+		}
+	default:
+		if prefix != "" {
+			envs[prefix] = scalarString(v)
+		}
+	}
+}
+
+func joinKey(prefix, key string) string {
+	key = normalizeKey(key)
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+func normalizeKey(key string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(strings.TrimSpace(key)))
+}
+
+// normalizePathKey normalizes a key named by its path in a key-value store,
+// such as db/host in etcd or db:host in Azure App Configuration, with _
+// between the segments.
+func normalizePathKey(key string) string {
+	return normalizeKey(strings.NewReplacer("/", "_", ":", "_").Replace(key))
+}
+
+func isScalarList(list []any) bool {
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]any, map[any]any, []any, []map[string]any:
+			return false
+		}
+	}
+	return true
+}
+
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
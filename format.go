@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+type Format string
+
+const (
+	FormatDotenv     Format = "dotenv"
+	FormatYAML       Format = "yaml"
+	FormatTOML       Format = "toml"
+	FormatJSON       Format = "json"
+	FormatJSONC      Format = "jsonc"
+	FormatHCL        Format = "hcl"
+	FormatINI        Format = "ini"
+	FormatProperties Format = "properties"
+	FormatXML        Format = "xml"
+	FormatCUE        Format = "cue"
+	FormatPlist      Format = "plist"
+)
+
+type fileFormat struct {
+	kind   string
+	decode decodeFunc
+}
+
+var formats = map[Format]fileFormat{
+	FormatDotenv:     {"dotenv", decodeDotenv},
+	FormatYAML:       {"YAML", decodeYAML},
+	FormatTOML:       {"TOML", decodeTOML},
+	FormatJSON:       {"JSON", decodeJSON},
+	FormatJSONC:      {"JSONC", decodeJSONC},
+	FormatHCL:        {"HCL", decodeHCL},
+	FormatINI:        {"INI", decodeINI},
+	FormatProperties: {"properties", decodeProperties},
+	FormatXML:        {"XML", decodeXML},
+	FormatCUE:        {"CUE", decodeCUEData},
+	FormatPlist:      {"plist", decodePlist},
+}
+
+var formatsByExt = map[string]Format{
+	".env":        FormatDotenv,
+	".yaml":       FormatYAML,
+	".yml":        FormatYAML,
+	".toml":       FormatTOML,
+	".json":       FormatJSON,
+	".jsonc":      FormatJSONC,
+	".hcl":        FormatHCL,
+	".ini":        FormatINI,
+	".properties": FormatProperties,
+	".xml":        FormatXML,
+	".cue":        FormatCUE,
+	".plist":      FormatPlist,
+}
+
+// formatForFile picks the format from the file extension, treating anything
+// unrecognised as dotenv.
+func formatForFile(file string) Format {
+	if format, ok := formatsByExt[strings.ToLower(filepath.Ext(file))]; ok {
+		return format
+	}
+	return FormatDotenv
+}
+
+func lookupFormat(format Format) (fileFormat, error) {
+	f, ok := formats[format]
+	if !ok {
+		return fileFormat{}, fmt.Errorf("unsupported config format %q", format)
+	}
+	return f, nil
+}
+
+func WithReader(r io.Reader, format Format) Option {
+	f, err := lookupFormat(format)
+	if err != nil {
+		return WithSource(SourceFunc(func() (map[string]string, error) {
+			return nil, err
+		}))
+	}
+	return withReader(f.kind, r, f.decode)
+}
+
+func NewReaderSource(r io.Reader, format Format) (Source, error) {
+	f, err := lookupFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	return newReaderSource(f.kind, r, f.decode), nil
+}
+
+func decodeDotenv(data []byte) (any, error) {
+	return godotenv.UnmarshalBytes(data)
+}
+
+func decodeFormat(format Format, data []byte) (map[string]string, error) {
+	f, err := lookupFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := f.decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return toEnvs(doc), nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"strconv"
+	"time"
+)
+
+// WithFS loads baked-in defaults, typically from a go:embed filesystem.
+// They only fill in values that no other source, environment variable or
+// option provides.
+func WithFS(fsys fs.FS, file string) Option {
+	return WithDefaults(NewFSSource(fsys, file))
+}
+
+func WithDefaults(sources ...Source) Option {
+	return func(c *Config) {
+		for _, src := range sources {
+			if src != nil {
+				c.defaults = append(c.defaults, src)
+			}
+		}
+	}
+}
+
+func NewFSSource(fsys fs.FS, file string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		f, err := lookupFormat(formatForFile(file))
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading embedded %s file: %w", f.kind, err)
+		}
+
+		doc, err := f.decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing embedded %s file %s: %w", f.kind, file, err)
+		}
+		return toEnvs(doc), nil
+	})
+}
+
+func (c *Config) applyDefaults(defaults map[string]string) {
+	if c.DatabaseURL == "" {
+		c.DatabaseURL = Secret(defaults["DATABASE_URL"])
+	}
+	if c.AuthServiceURL == "" {
+		c.AuthServiceURL = defaults["AUTH_SERVICE_URL"]
+	}
+	if !c.explicit["DEBUG"] {
+		if value, exists := defaults["DEBUG"]; exists && value != "" {
+			if debug, err := strconv.ParseBool(value); err != nil {
+				log.Printf("Warning: invalid boolean default for DEBUG, ignoring")
+			} else {
+				c.Debug = debug
+			}
+		}
+	}
+	if c.Port == "" {
+		c.Port = defaults["PORT"]
+	}
+	if c.HTTPTimeout == 0 {
+		c.HTTPTimeout = defaultDuration(defaults, "HTTP_TIMEOUT")
+	}
+	if c.ShutdownGrace == 0 {
+		c.ShutdownGrace = defaultDuration(defaults, "SHUTDOWN_GRACE")
+	}
+	if c.LogFormat == "" {
+		c.LogFormat = defaults["LOG_FORMAT"]
+	}
+	if !c.explicit["LOG_LEVEL"] {
+		if value, exists := defaults["LOG_LEVEL"]; exists && value != "" {
+			if level, err := parseLogLevel(value); err != nil {
+				log.Printf("Warning: invalid log level default for LOG_LEVEL, ignoring")
+			} else {
+				c.LogLevel = level
+			}
+		}
+	}
+}
+
+// setExplicit records that an option set the built-in key, so defaults do
+// not override it.
+func (c *Config) setExplicit(key string) {
+	if c.explicit == nil {
+		c.explicit = make(map[string]bool)
+	}
+	c.explicit[key] = true
+}
+
+func defaultDuration(defaults map[string]string, key string) time.Duration {
+	value, exists := defaults[key]
+	if !exists || value == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid duration default for %s, ignoring", key)
+	}
+	return duration
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// gcpClient authenticates with Application Default Credentials: the file
+// in GOOGLE_APPLICATION_CREDENTIALS (service account key or authorized
+// user), the gcloud ADC file, and finally the metadata server, which also
+// serves GKE Workload Identity tokens.
+type gcpClient struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type gcpCredentialsFile struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	ProjectID    string `json:"project_id"`
+}
+
+func newGCPClient(client *http.Client) *gcpClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &gcpClient{client: client}
+}
+
+func (g *gcpClient) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return resp, fmt.Errorf("unexpected status %s: %s", resp.Status, apiErr.Error.Message)
+		}
+		return resp, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp, nil
+}
+
+func (g *gcpClient) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.token != "" && time.Until(g.expires) > time.Minute {
+		return g.token, nil
+	}
+
+	creds, err := readGCPCredentialsFile()
+	if err != nil {
+		return "", err
+	}
+
+	var token string
+	var expiresIn int
+	switch {
+	case creds == nil:
+		token, expiresIn, err = g.metadataToken(ctx)
+	case creds.Type == "service_account":
+		token, expiresIn, err = g.serviceAccountToken(ctx, creds)
+	case creds.Type == "authorized_user":
+		token, expiresIn, err = g.exchangeToken(ctx, "https://oauth2.googleapis.com/token", url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {creds.ClientID},
+			"client_secret": {creds.ClientSecret},
+			"refresh_token": {creds.RefreshToken},
+		})
+	default:
+		err = fmt.Errorf("unsupported credentials type %q", creds.Type)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error obtaining Google access token: %w", err)
+	}
+
+	g.token = token
+	g.expires = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return token, nil
+}
+
+func readGCPCredentialsFile() (*gcpCredentialsFile, error) {
+	file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if file == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, nil
+		}
+		file = filepath.Join(dir, "gcloud", "application_default_credentials.json")
+		if _, err := os.Stat(file); err != nil {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Google credentials: %w", err)
+	}
+	var creds gcpCredentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("error parsing Google credentials %s: %w", file, err)
+	}
+	return &creds, nil
+}
+
+func (g *gcpClient) metadataToken(ctx context.Context) (string, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gceMetadataEndpoint+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return g.decodeToken(req)
+}
+
+func (g *gcpClient) serviceAccountToken(ctx context.Context, creds *gcpCredentialsFile) (string, int, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", 0, errors.New("invalid service account private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", 0, errors.New("service account private key is not RSA")
+	}
+
+	tokenURI := creds.TokenURI
+	if tokenURI == "" {
+		tokenURI = "https://oauth2.googleapis.com/token"
+	}
+	now := time.Now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iss":   creds.ClientEmail,
+		"scope": gcpCloudPlatformScope,
+		"aud":   tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", 0, err
+	}
+
+	return g.exchangeToken(ctx, tokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)},
+	})
+}
+
+func (g *gcpClient) exchangeToken(ctx context.Context, tokenURI string, form url.Values) (string, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return g.decodeToken(req)
+}
+
+func (g *gcpClient) decodeToken(req *http.Request) (string, int, error) {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("error decoding token response: %w", err)
+	}
+	return token.AccessToken, token.ExpiresIn, nil
+}
+
+// gcpProject resolves the project from GOOGLE_CLOUD_PROJECT, the
+// credentials file, then the metadata server.
+func gcpProject(ctx context.Context, client *http.Client) (string, error) {
+	if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" {
+		return project, nil
+	}
+	if creds, err := readGCPCredentialsFile(); err == nil && creds != nil && creds.ProjectID != "" {
+		return creds.ProjectID, nil
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gceMetadataEndpoint+"/computeMetadata/v1/project/project-id", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.New("Google Cloud project is not set: configure it or set GOOGLE_CLOUD_PROJECT")
+	}
+	defer resp.Body.Close()
+	project, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return "", errors.New("Google Cloud project is not set: configure it or set GOOGLE_CLOUD_PROJECT")
+	}
+	return strings.TrimSpace(string(project)), nil
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGCPServiceAccountToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	var assertion string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if got := r.PostForm.Get("grant_type"); got != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("grant_type = %q", got)
+		}
+		assertion = r.PostForm.Get("assertion")
+		w.Write([]byte(`{"access_token":"ya29.token","expires_in":3600}`))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr string
+	}{
+		{"valid", privateKey, ""},
+		{"not pem", "not a key", "invalid service account private key"},
+		{"not pkcs8", string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")})), "invalid service account private key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds := &gcpCredentialsFile{
+				Type:        "service_account",
+				ClientEmail: "app@project.iam.gserviceaccount.com",
+				PrivateKey:  tt.key,
+				TokenURI:    srv.URL,
+			}
+			token, expiresIn, err := newGCPClient(srv.Client()).serviceAccountToken(context.Background(), creds)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("serviceAccountToken error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("serviceAccountToken: %v", err)
+			}
+			if token != "ya29.token" || expiresIn != 3600 {
+				t.Errorf("serviceAccountToken = %q, %d", token, expiresIn)
+			}
+
+			parts := strings.Split(assertion, ".")
+			if len(parts) != 3 {
+				t.Fatalf("assertion has %d parts, want 3", len(parts))
+			}
+			header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+			if string(header) != `{"alg":"RS256","typ":"JWT"}` {
+				t.Errorf("header = %s", header)
+			}
+			var claims struct {
+				Iss   string `json:"iss"`
+				Scope string `json:"scope"`
+				Aud   string `json:"aud"`
+				Iat   int64  `json:"iat"`
+				Exp   int64  `json:"exp"`
+			}
+			payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			if err := json.Unmarshal(payload, &claims); err != nil {
+				t.Fatalf("claims: %v", err)
+			}
+			if claims.Iss != creds.ClientEmail || claims.Scope != gcpCloudPlatformScope || claims.Aud != srv.URL || claims.Exp-claims.Iat != 3600 {
+				t.Errorf("claims = %+v", claims)
+			}
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+				t.Errorf("signature does not verify: %v", err)
+			}
+		})
+	}
+}
+
+// redirectTransport sends every request to the test server, so the fixed
+// Google token endpoint can be served locally.
+type redirectTransport struct{ target string }
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = "http", strings.TrimPrefix(rt.target, "http://")
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGCPAccessToken(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		if r.URL.Path != "/token" || r.PostForm.Get("grant_type") != "refresh_token" || r.PostForm.Get("refresh_token") != "refresh" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"user-token","expires_in":3600}`))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: redirectTransport{srv.URL}}
+
+	tests := []struct {
+		name    string
+		creds   string
+		want    string
+		wantErr string
+	}{
+		{"authorized user", `{"type":"authorized_user","client_id":"id","client_secret":"secret","refresh_token":"refresh"}`, "user-token", ""},
+		{"rejected refresh token", `{"type":"authorized_user","refresh_token":"revoked"}`, "", "token endpoint returned 400 Bad Request"},
+		{"unsupported type", `{"type":"external_account"}`, "", `unsupported credentials type "external_account"`},
+		{"invalid json", `{`, "", "error parsing Google credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "credentials.json")
+			if err := os.WriteFile(file, []byte(tt.creds), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+
+			g := newGCPClient(client)
+			token, err := g.accessToken(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("accessToken error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || token != tt.want {
+				t.Fatalf("accessToken = %q, %v, want %q", token, err, tt.want)
+			}
+
+			before := requests
+			if token, err := g.accessToken(context.Background()); err != nil || token != tt.want || requests != before {
+				t.Errorf("second accessToken = %q, %v after %d requests, want the cached token", token, err, requests-before)
+			}
+		})
+	}
+}
+
+func TestGCPProject(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   string
+		creds string
+		want  string
+	}{
+		{"environment", "env-project", `{"project_id":"file-project"}`, "env-project"},
+		{"credentials file", "", `{"project_id":"file-project"}`, "file-project"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "credentials.json")
+			if err := os.WriteFile(file, []byte(tt.creds), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+			t.Setenv("GOOGLE_CLOUD_PROJECT", tt.env)
+
+			project, err := gcpProject(context.Background(), nil)
+			if err != nil || project != tt.want {
+				t.Errorf("gcpProject = %q, %v, want %q", project, err, tt.want)
+			}
+		})
+	}
+}
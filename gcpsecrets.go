@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GCPSecretSource resolves individual keys from Google Secret Manager.
+type GCPSecretSource struct {
+	Project string
+	// Endpoint overrides https://secretmanager.googleapis.com.
+	Endpoint string
+	// Timeout defaults to 30s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	secrets map[string]string
+
+	once sync.Once
+	gcp  *gcpClient
+}
+
+const defaultGCPSecretTimeout = 30 * time.Second
+
+func NewGCPSecretSource(project string) *GCPSecretSource {
+	return &GCPSecretSource{
+		Project:  project,
+		Endpoint: "https://secretmanager.googleapis.com",
+		Timeout:  defaultGCPSecretTimeout,
+		secrets:  make(map[string]string),
+	}
+}
+
+// WithSecret maps key to a secret. secret is either a secret name in the
+// source's project, whose latest version is used, or a full resource name
+// such as projects/p/secrets/db-url/versions/3.
+func (s *GCPSecretSource) WithSecret(key, secret string) *GCPSecretSource {
+	s.secrets[key] = secret
+	return s
+}
+
+func (s *GCPSecretSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	s.once.Do(func() { s.gcp = newGCPClient(s.Client) })
+
+	keys := make([]string, 0, len(s.secrets))
+	for key := range s.secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	envs := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name, err := s.versionName(ctx, s.secrets[key])
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.access(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("error accessing secret %s for %s: %w", name, key, err)
+		}
+		envs[key] = value
+	}
+	return envs, nil
+}
+
+func (s *GCPSecretSource) versionName(ctx context.Context, secret string) (string, error) {
+	if strings.HasPrefix(secret, "projects/") {
+		if !strings.Contains(secret, "/versions/") {
+			secret += "/versions/latest"
+		}
+		return secret, nil
+	}
+	if s.Project == "" {
+		project, err := gcpProject(ctx, s.Client)
+		if err != nil {
+			return "", err
+		}
+		s.Project = project
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/latest", s.Project, secret), nil
+}
+
+func (s *GCPSecretSource) access(ctx context.Context, name string) (string, error) {
+	resp, err := s.gcp.get(ctx, strings.TrimSuffix(s.Endpoint, "/")+"/v1/"+name+":access")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("error decoding response: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+	return string(data), nil
+}
+
+func (s *GCPSecretSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultGCPSecretTimeout)
+}
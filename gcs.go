@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GCSSource loads a config object from Google Cloud Storage. Watching polls
+// with ifGenerationNotMatch, so the object is only downloaded again once a
+// new generation has been written.
+type GCSSource struct {
+	Bucket string
+	Object string
+	// Format of the object; derived from Object when empty.
+	Format Format
+	// Endpoint overrides https://storage.googleapis.com.
+	Endpoint string
+	// PollInterval and Timeout default to 1m and 30s when not positive.
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Client       *http.Client
+
+	once sync.Once
+	gcp  *gcpClient
+
+	mu         sync.Mutex
+	generation string
+	cached     map[string]string
+}
+
+const (
+	defaultGCSEndpoint     = "https://storage.googleapis.com"
+	defaultGCSPollInterval = time.Minute
+	defaultGCSTimeout      = 30 * time.Second
+)
+
+func NewGCSSource(bucket, object string) *GCSSource {
+	return &GCSSource{
+		Bucket:       bucket,
+		Object:       object,
+		Endpoint:     defaultGCSEndpoint,
+		PollInterval: defaultGCSPollInterval,
+		Timeout:      defaultGCSTimeout,
+	}
+}
+
+func (s *GCSSource) Load() (map[string]string, error) {
+	values, _, err := s.fetch(context.Background())
+	return values, err
+}
+
+func (s *GCSSource) Watch(ctx context.Context, changed func()) error {
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, modified, err := s.fetch(ctx)
+			if err != nil {
+				logWatchError("GCS", s.Bucket+"/"+s.Object, err)
+				continue
+			}
+			if modified {
+				changed()
+			}
+		}
+	}
+}
+
+func (s *GCSSource) fetch(ctx context.Context) (map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	s.once.Do(func() { s.gcp = newGCPClient(s.Client) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := url.Values{"alt": {"media"}}
+	if s.generation != "" {
+		query.Set("ifGenerationNotMatch", s.generation)
+	}
+	objectURL := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?%s",
+		strings.TrimSuffix(s.endpoint(), "/"), url.PathEscape(s.Bucket), url.PathEscape(s.Object), query.Encode())
+
+	resp, err := s.gcp.get(ctx, objectURL)
+	if err != nil {
+		return nil, false, fmt.Errorf("error fetching gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && s.cached != nil {
+		return copyEnvs(s.cached), false, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+
+	format := s.Format
+	if format == "" {
+		format = formatForFile(s.Object)
+	}
+	values, err := decodeFormat(format, data)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing gs://%s/%s: %w", s.Bucket, s.Object, err)
+	}
+
+	s.generation = resp.Header.Get("X-Goog-Generation")
+	s.cached = values
+	return copyEnvs(values), true, nil
+}
+
+func (s *GCSSource) endpoint() string {
+	if s.Endpoint == "" {
+		return defaultGCSEndpoint
+	}
+	return s.Endpoint
+}
+
+func (s *GCSSource) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultGCSPollInterval)
+}
+
+func (s *GCSSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultGCSTimeout)
+}
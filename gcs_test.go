@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGCSSource(t *testing.T) {
+	creds := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(creds, []byte(`{"type":"authorized_user","refresh_token":"refresh"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", creds)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want Bearer token", got)
+		}
+		if want := "/storage/v1/b/bucket/o/envs%2Fprod.json"; r.URL.EscapedPath() != want {
+			t.Errorf("path = %q, want %q", r.URL.EscapedPath(), want)
+		}
+		if r.URL.Query().Get("ifGenerationNotMatch") == "7" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("X-Goog-Generation", "7")
+		w.Write([]byte(`{"port": 8080}`))
+	}))
+	defer srv.Close()
+
+	// A struct literal, without the constructor's endpoint and durations.
+	s := &GCSSource{Bucket: "bucket", Object: "envs/prod.json", Client: &http.Client{Transport: redirectTransport{srv.URL}}}
+	if got := s.endpoint(); got != defaultGCSEndpoint {
+		t.Errorf("endpoint = %q, want %q", got, defaultGCSEndpoint)
+	}
+	tests := []struct {
+		name         string
+		wantModified bool
+	}{
+		{"first fetch", true},
+		{"same generation", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, modified, err := s.fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if want := map[string]string{"PORT": "8080"}; !maps.Equal(values, want) || modified != tt.wantModified {
+				t.Errorf("fetch = %v, %v, want %v, %v", values, modified, want, tt.wantModified)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitSource reads a single file at a ref of a git repository using the git
+// CLI. Only the objects for that ref are fetched; no working tree is
+// checked out.
+type GitSource struct {
+	Repo string
+	Ref  string
+	Path string
+	// Format of the file; derived from Path when empty.
+	Format Format
+	// Dir holds the local bare repository. A temporary directory is used
+	// when empty; Close removes it.
+	Dir string
+	// Timeout and PollInterval default to 1m and 5m when not positive.
+	Timeout      time.Duration
+	PollInterval time.Duration
+
+	mu      sync.Mutex
+	tempDir bool
+	commit  string
+	cached  map[string]string
+}
+
+const (
+	defaultGitTimeout      = time.Minute
+	defaultGitPollInterval = 5 * time.Minute
+)
+
+func NewGitSource(repo, ref, path string) *GitSource {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	return &GitSource{
+		Repo:         repo,
+		Ref:          ref,
+		Path:         path,
+		Timeout:      defaultGitTimeout,
+		PollInterval: defaultGitPollInterval,
+	}
+}
+
+func (s *GitSource) Load() (map[string]string, error) {
+	values, _, err := s.fetch(context.Background())
+	return values, err
+}
+
+func (s *GitSource) Watch(ctx context.Context, changed func()) error {
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, modified, err := s.fetch(ctx)
+			if err != nil {
+				logWatchError("git", s.Repo, err)
+				continue
+			}
+			if modified {
+				changed()
+			}
+		}
+	}
+}
+
+// Close removes the temporary repository created when Dir was empty. A Dir
+// set by the caller is left in place.
+func (s *GitSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.tempDir {
+		return nil
+	}
+	err := os.RemoveAll(s.Dir)
+	s.Dir, s.tempDir, s.commit, s.cached = "", false, "", nil
+	return err
+}
+
+func (s *GitSource) fetch(ctx context.Context) (map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Dir == "" {
+		dir, err := os.MkdirTemp("", "config-git-")
+		if err != nil {
+			return nil, false, fmt.Errorf("error creating git cache directory: %w", err)
+		}
+		s.Dir, s.tempDir = dir, true
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "HEAD")); os.IsNotExist(err) {
+		if _, err := s.git(ctx, "init", "--bare", "--quiet"); err != nil {
+			return nil, false, err
+		}
+	}
+
+	ref := s.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := s.git(ctx, "fetch", "--quiet", "--depth", "1", s.Repo, ref); err != nil {
+		return nil, false, err
+	}
+	commit, err := s.git(ctx, "rev-parse", "FETCH_HEAD")
+	if err != nil {
+		return nil, false, err
+	}
+	commit = strings.TrimSpace(commit)
+	if commit == s.commit && s.cached != nil {
+		return copyEnvs(s.cached), false, nil
+	}
+
+	content, err := s.git(ctx, "show", "FETCH_HEAD:"+s.Path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	format := s.Format
+	if format == "" {
+		format = formatForFile(s.Path)
+	}
+	values, err := decodeFormat(format, []byte(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing %s from %s@%s: %w", s.Path, s.Repo, ref, err)
+	}
+
+	s.commit = commit
+	s.cached = values
+	return copyEnvs(values), true, nil
+}
+
+func (s *GitSource) git(ctx context.Context, command string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"--git-dir", s.Dir, command}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func (s *GitSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultGitTimeout)
+}
+
+func (s *GitSource) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultGitPollInterval)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	commit := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "config.json"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "config.json")
+		run("commit", "--quiet", "-m", "update")
+	}
+	run("init", "--quiet")
+	commit(`{"port": 8080}`)
+
+	// A struct literal, without the constructor's ref and durations.
+	s := &GitSource{Repo: repo, Path: "config.json"}
+	defer s.Close()
+
+	tests := []struct {
+		name         string
+		content      string
+		want         map[string]string
+		wantModified bool
+	}{
+		{"first fetch", "", map[string]string{"PORT": "8080"}, true},
+		{"same commit", "", map[string]string{"PORT": "8080"}, false},
+		{"new commit", `{"port": 9090}`, map[string]string{"PORT": "9090"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.content != "" {
+				commit(tt.content)
+			}
+			values, modified, err := s.fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if !maps.Equal(values, tt.want) || modified != tt.wantModified {
+				t.Errorf("fetch = %v, %v, want %v, %v", values, modified, tt.want, tt.wantModified)
+			}
+		})
+	}
+
+	dir := s.Dir
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temporary repository %s still exists after Close", dir)
+	}
+}
@@ -3,3 +3,20 @@ module github.com/baditaflorin/go-config-module
 go 1.22.2
 
 require github.com/joho/godotenv v1.5.1
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/BurntSushi/toml v1.4.0
+
+require github.com/hashicorp/hcl v1.0.0
+
+require golang.org/x/sys v0.25.0
+
+require golang.org/x/net v0.25.0
+
+require (
+	cuelang.org/go v0.9.2
+	github.com/cockroachdb/apd/v3 v3.2.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+)
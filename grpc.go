@@ -0,0 +1,320 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// GRPCSource loads configuration from a central service speaking the
+// config.v1.ConfigService protocol in proto/config/v1/config_service.proto.
+// Watching holds a WatchConfig stream open and reloads on every new version.
+type GRPCSource struct {
+	// Target is the service's base URL. http:// targets use cleartext
+	// HTTP/2, https:// targets use TLS.
+	Target      string
+	Service     string
+	Environment string
+	// Token is sent as a bearer token in the authorization metadata.
+	Token string
+	// Timeout defaults to 10s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	once    sync.Once
+	client  *http.Client
+	mu      sync.Mutex
+	version string
+}
+
+const defaultGRPCTimeout = 10 * time.Second
+
+func NewGRPCSource(target, service string) *GRPCSource {
+	return &GRPCSource{
+		Target:  strings.TrimSuffix(target, "/"),
+		Service: service,
+		Timeout: defaultGRPCTimeout,
+	}
+}
+
+const grpcServicePath = "/config.v1.ConfigService/"
+
+func (s *GRPCSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	resp, err := s.call(ctx, "GetConfig", "")
+	if err != nil {
+		return nil, fmt.Errorf("error fetching config for %s: %w", s.Service, err)
+	}
+	defer resp.Body.Close()
+
+	msg, err := readGRPCMessage(resp.Body)
+	if err == io.EOF {
+		err = grpcStatus(resp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error fetching config for %s: %w", s.Service, err)
+	}
+	values, version, err := decodeConfigSnapshot(msg)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding config for %s: %w", s.Service, err)
+	}
+
+	s.mu.Lock()
+	s.version = version
+	s.mu.Unlock()
+	return values, nil
+}
+
+func (s *GRPCSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		err := s.watchOnce(ctx, changed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logWatchError("gRPC", s.Service, err)
+		if !sleepContext(ctx, 5*time.Second) {
+			return nil
+		}
+	}
+}
+
+func (s *GRPCSource) watchOnce(ctx context.Context, changed func()) error {
+	s.mu.Lock()
+	version := s.version
+	s.mu.Unlock()
+
+	resp, err := s.call(ctx, "WatchConfig", version)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	for {
+		msg, err := readGRPCMessage(resp.Body)
+		if err == io.EOF {
+			if err := grpcStatus(resp); err != nil {
+				return err
+			}
+			return errors.New("stream closed by server")
+		}
+		if err != nil {
+			return err
+		}
+		_, next, err := decodeConfigSnapshot(msg)
+		if err != nil {
+			return fmt.Errorf("invalid snapshot: %w", err)
+		}
+
+		s.mu.Lock()
+		seen := s.version
+		s.version = next
+		s.mu.Unlock()
+		if next != seen {
+			changed()
+		}
+	}
+}
+
+func (s *GRPCSource) call(ctx context.Context, method, version string) (*http.Response, error) {
+	var body bytes.Buffer
+	appendGRPCMessage(&body, encodeConfigRequest(s.Service, s.Environment, version))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Target+grpcServicePath+method, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc+proto")
+	req.Header.Set("TE", "trailers")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	// Responses without a payload, errors mostly, arrive as headers only. An
+	// OK status reads as an empty response.
+	if resp.Header.Get("Grpc-Status") != "" {
+		resp.Body.Close()
+		if err := grpcStatus(resp); err != nil {
+			return nil, err
+		}
+		resp.Body = http.NoBody
+	}
+	return resp, nil
+}
+
+func (s *GRPCSource) httpClient() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	s.once.Do(func() {
+		transport := &http2.Transport{}
+		if u, err := url.Parse(s.Target); err == nil && u.Scheme == "http" {
+			transport.AllowHTTP = true
+			transport.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			}
+		}
+		s.client = &http.Client{Transport: transport}
+	})
+	return s.client
+}
+
+func grpcStatus(resp *http.Response) error {
+	status := resp.Trailer.Get("Grpc-Status")
+	message := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status = resp.Header.Get("Grpc-Status")
+		message = resp.Header.Get("Grpc-Message")
+	}
+	if status == "" || status == "0" {
+		return nil
+	}
+	if decoded, err := url.PathUnescape(message); err == nil {
+		message = decoded
+	}
+	return fmt.Errorf("gRPC status %s: %s", status, message)
+}
+
+func appendGRPCMessage(w *bytes.Buffer, msg []byte) {
+	w.WriteByte(0)
+	binary.Write(w, binary.BigEndian, uint32(len(msg)))
+	w.Write(msg)
+}
+
+func readGRPCMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("truncated gRPC message")
+		}
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed gRPC messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(prefix[1:])
+	if n > 16<<20 {
+		return nil, fmt.Errorf("gRPC message too large (%d bytes)", n)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, errors.New("truncated gRPC message")
+	}
+	return msg, nil
+}
+
+// The messages are small enough to encode by hand rather than pulling in
+// generated protobuf code.
+
+func encodeConfigRequest(service, environment, version string) []byte {
+	var msg []byte
+	msg = appendProtoString(msg, 1, service)
+	msg = appendProtoString(msg, 2, environment)
+	msg = appendProtoString(msg, 3, version)
+	return msg
+}
+
+func appendProtoString(msg []byte, field int, value string) []byte {
+	if value == "" {
+		return msg
+	}
+	msg = binary.AppendUvarint(msg, uint64(field)<<3|2)
+	msg = binary.AppendUvarint(msg, uint64(len(value)))
+	return append(msg, value...)
+}
+
+func decodeConfigSnapshot(msg []byte) (map[string]string, string, error) {
+	values := make(map[string]string)
+	var version string
+	err := readProtoFields(msg, func(field int, data []byte) error {
+		switch field {
+		case 1:
+			var key, value string
+			err := readProtoFields(data, func(field int, data []byte) error {
+				switch field {
+				case 1:
+					key = string(data)
+				case 2:
+					value = string(data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			values[key] = value
+		case 2:
+			version = string(data)
+		}
+		return nil
+	})
+	return values, version, err
+}
+
+// readProtoFields calls fn for every length-delimited field and skips the
+// rest.
+func readProtoFields(msg []byte, fn func(field int, data []byte) error) error {
+	for len(msg) > 0 {
+		tag, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return errors.New("invalid field tag")
+		}
+		msg = msg[n:]
+
+		var size uint64
+		switch tag & 7 {
+		case 0:
+			if _, n = binary.Uvarint(msg); n <= 0 {
+				return errors.New("invalid varint")
+			}
+			msg = msg[n:]
+			continue
+		case 1:
+			size = 8
+		case 5:
+			size = 4
+		case 2:
+			if size, n = binary.Uvarint(msg); n <= 0 {
+				return errors.New("invalid length")
+			}
+			msg = msg[n:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", tag&7)
+		}
+		if size > uint64(len(msg)) {
+			return errors.New("truncated field")
+		}
+		if tag&7 == 2 {
+			if err := fn(int(tag>>3), msg[:size]); err != nil {
+				return err
+			}
+		}
+		msg = msg[size:]
+	}
+	return nil
+}
+
+func (s *GRPCSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultGRPCTimeout)
+}
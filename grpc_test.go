@@ -0,0 +1,297 @@
+package config
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGRPCMessageFraming(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"empty", nil},
+		{"short", []byte("hello")},
+		{"binary", []byte{0, 1, 2, 0xff}},
+		{"large", bytes.Repeat([]byte("x"), 70000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			appendGRPCMessage(&buf, tt.msg)
+			if got := buf.Len(); got != 5+len(tt.msg) {
+				t.Fatalf("framed length = %d, want %d", got, 5+len(tt.msg))
+			}
+			msg, err := readGRPCMessage(&buf)
+			if err != nil {
+				t.Fatalf("readGRPCMessage: %v", err)
+			}
+			if !bytes.Equal(msg, tt.msg) {
+				t.Errorf("readGRPCMessage = %q, want %q", msg, tt.msg)
+			}
+			if _, err := readGRPCMessage(&buf); err != io.EOF {
+				t.Errorf("second read error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestReadGRPCMessageErrors(t *testing.T) {
+	frame := func(flag byte, n uint32, body string) []byte {
+		b := []byte{flag}
+		b = binary.BigEndian.AppendUint32(b, n)
+		return append(b, body...)
+	}
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"truncated prefix", []byte{0, 0, 0}, "truncated gRPC message"},
+		{"truncated body", frame(0, 10, "abc"), "truncated gRPC message"},
+		{"compressed", frame(1, 3, "abc"), "compressed gRPC messages are not supported"},
+		{"too large", frame(0, 17<<20, ""), "gRPC message too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readGRPCMessage(bytes.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("readGRPCMessage error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeConfigRequest(t *testing.T) {
+	tests := []struct {
+		name                  string
+		service, env, version string
+		want                  []byte
+	}{
+		{"all fields", "api", "prod", "v1", []byte("\x0a\x03api\x12\x04prod\x1a\x02v1")},
+		{"empty fields omitted", "api", "", "", []byte("\x0a\x03api")},
+		{"nothing", "", "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeConfigRequest(tt.service, tt.env, tt.version); !bytes.Equal(got, tt.want) {
+				t.Errorf("encodeConfigRequest = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigSnapshot(t *testing.T) {
+	entry := func(key, value string) []byte {
+		return appendProtoString(appendProtoString(nil, 1, key), 2, value)
+	}
+	snapshot := func(version string, entries ...[]byte) []byte {
+		var msg []byte
+		for _, e := range entries {
+			msg = binary.AppendUvarint(msg, 1<<3|2)
+			msg = binary.AppendUvarint(msg, uint64(len(e)))
+			msg = append(msg, e...)
+		}
+		return appendProtoString(msg, 2, version)
+	}
+
+	tests := []struct {
+		name        string
+		msg         []byte
+		wantValues  map[string]string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "empty",
+			wantValues:  map[string]string{},
+			wantVersion: "",
+		},
+		{
+			name:        "entries and version",
+			msg:         snapshot("7", entry("PORT", "8080"), entry("DEBUG", "true")),
+			wantValues:  map[string]string{"PORT": "8080", "DEBUG": "true"},
+			wantVersion: "7",
+		},
+		{
+			name:        "empty value",
+			msg:         snapshot("", entry("NAME", "")),
+			wantValues:  map[string]string{"NAME": ""},
+			wantVersion: "",
+		},
+		{
+			name: "unknown fields skipped",
+			msg: slices.Concat(
+				[]byte{3<<3 | 0, 0x96, 0x01},             // varint
+				[]byte{4<<3 | 1, 0, 0, 0, 0, 0, 0, 0, 0}, // fixed64
+				[]byte{5<<3 | 5, 0, 0, 0, 0},             // fixed32
+				snapshot("2", entry("A", "b")),
+			),
+			wantValues:  map[string]string{"A": "b"},
+			wantVersion: "2",
+		},
+		{
+			name:    "truncated field",
+			msg:     []byte{1<<3 | 2, 10, 'a'},
+			wantErr: "truncated field",
+		},
+		{
+			name:    "invalid length",
+			msg:     []byte{1<<3 | 2, 0x80},
+			wantErr: "invalid length",
+		},
+		{
+			name:    "unsupported wire type",
+			msg:     []byte{1<<3 | 3},
+			wantErr: "unsupported wire type 3",
+		},
+		{
+			name:    "invalid nested entry",
+			msg:     []byte{1<<3 | 2, 2, 1<<3 | 2, 5},
+			wantErr: "truncated field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, version, err := decodeConfigSnapshot(tt.msg)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("decodeConfigSnapshot error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeConfigSnapshot: %v", err)
+			}
+			if !maps.Equal(values, tt.wantValues) {
+				t.Errorf("values = %v, want %v", values, tt.wantValues)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %q, want %q", version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestGRPCStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		trailer http.Header
+		want    string
+	}{
+		{"none", http.Header{}, http.Header{}, ""},
+		{"ok trailer", http.Header{}, http.Header{"Grpc-Status": {"0"}}, ""},
+		{"error trailer", http.Header{}, http.Header{"Grpc-Status": {"5"}, "Grpc-Message": {"not%20found"}}, "gRPC status 5: not found"},
+		{"error header", http.Header{"Grpc-Status": {"16"}, "Grpc-Message": {"bad token"}}, http.Header{}, "gRPC status 16: bad token"},
+		{"trailer wins", http.Header{"Grpc-Status": {"0"}}, http.Header{"Grpc-Status": {"13"}, "Grpc-Message": {"boom"}}, "gRPC status 13: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := grpcStatus(&http.Response{Header: tt.header, Trailer: tt.trailer})
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("grpcStatus = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("grpcStatus = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCSourceLoad(t *testing.T) {
+	entry := appendProtoString(appendProtoString(nil, 1, "PORT"), 2, "8080")
+	snapshot := append([]byte{1<<3 | 2, byte(len(entry))}, entry...)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "message",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "Grpc-Status")
+				var body bytes.Buffer
+				appendGRPCMessage(&body, snapshot)
+				w.Write(body.Bytes())
+				w.Header().Set("Grpc-Status", "0")
+			},
+			want: map[string]string{"PORT": "8080"},
+		},
+		{
+			name: "trailers only ok",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Grpc-Status", "0")
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "trailers only error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Grpc-Status", "7")
+				w.Header().Set("Grpc-Message", "denied")
+			},
+			wantErr: "gRPC status 7: denied",
+		},
+		{
+			name: "error trailer",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+				w.WriteHeader(http.StatusOK)
+				w.Header().Set("Grpc-Status", "14")
+				w.Header().Set("Grpc-Message", "unavailable")
+			},
+			wantErr: "gRPC status 14: unavailable",
+		},
+		{
+			name: "http error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadGateway)
+			},
+			wantErr: "unexpected status 502",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != grpcServicePath+"GetConfig" {
+					t.Errorf("path = %s, want %sGetConfig", r.URL.Path, grpcServicePath)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if msg, err := readGRPCMessage(bytes.NewReader(body)); err != nil || !bytes.Equal(msg, encodeConfigRequest("api", "", "")) {
+					t.Errorf("request message = %q, %v", msg, err)
+				}
+				tt.handler(w, r)
+			}))
+			defer srv.Close()
+
+			s := NewGRPCSource(srv.URL, "api")
+			s.Client = srv.Client()
+			values, err := s.Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !maps.Equal(values, tt.want) {
+				t.Errorf("Load = %v, want %v", values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"github.com/hashicorp/hcl"
+)
+
+func WithHCLFile(file string) Option {
+	return withFile("HCL", file, decodeHCL)
+}
+
+func decodeHCL(data []byte) (any, error) {
+	var doc map[string]any
+	if err := hcl.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return unwrapHCLBlocks(doc), nil
+}
+
+// unwrapHCLBlocks collapses the single-element lists HCL produces for each
+// block so that `auth { url = "..." }` flattens to AUTH_URL rather than
+// AUTH_0_URL. Repeated blocks keep their list form and are indexed.
+func unwrapHCLBlocks(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			v[key] = unwrapHCLBlocks(child)
+		}
+		return v
+	case []map[string]any:
+		if len(v) == 1 {
+			return unwrapHCLBlocks(v[0])
+		}
+		for i, item := range v {
+			v[i] = unwrapHCLBlocks(item).(map[string]any)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// HostPort is a validated host:port pair such as LISTEN_ADDR=:8080 or
+// UPSTREAM=api.internal:https. Named ports are resolved to numbers.
+type HostPort struct {
+	host string
+	port int
+}
+
+func (h HostPort) Host() string {
+	return h.host
+}
+
+func (h HostPort) Port() int {
+	return h.port
+}
+
+func (h HostPort) String() string {
+	return net.JoinHostPort(h.host, strconv.Itoa(h.port))
+}
+
+func (h HostPort) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *HostPort) UnmarshalText(text []byte) error {
+	parsed, err := parseHostPort(string(text))
+	if err != nil {
+		return err
+	}
+	*h = parsed
+	return nil
+}
+
+// WithHostPortKeys declares host:port keys to be validated by NewConfig.
+func WithHostPortKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parseHostPort(value)
+		return err
+	})
+}
+
+func (c *Config) GetHostPort(key string) (HostPort, error) {
+	return getValue(c, key, parseHostPort)
+}
+
+func (c *Config) GetHostPortWithFallback(key string, fallback HostPort) HostPort {
+	return getValueWithFallback(c, key, "host:port", fallback, parseHostPort)
+}
+
+func parseHostPort(s string) (HostPort, error) {
+	host, portName, err := net.SplitHostPort(s)
+	if err != nil {
+		return HostPort{}, err
+	}
+	if host != "" && net.ParseIP(host) == nil && !isHostname(host) {
+		return HostPort{}, fmt.Errorf("%q is not a valid host", host)
+	}
+	port, err := net.LookupPort("tcp", portName)
+	if err != nil || portName == "" {
+		return HostPort{}, fmt.Errorf("%q is not a valid port", portName)
+	}
+	return HostPort{host: host, port: port}, nil
+}
+
+// WithPortKeys declares keys holding TCP ports, checked by NewConfig.
+func WithPortKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parsePort(value)
+		return err
+	})
+}
+
+// GetPort reads a port number in 1-65535, such as METRICS_PORT=9090.
+func (c *Config) GetPort(key string) (int, error) {
+	return getValue(c, key, parsePort)
+}
+
+func (c *Config) GetPortWithFallback(key string, fallback int) int {
+	return getValueWithFallback(c, key, "port", fallback, parsePort)
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("%q is not a port number between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+func isHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+)
+
+type HTTPSource struct {
+	URL string
+	// Format of the response body. When empty it is derived from the
+	// Content-Type header, then from the URL path extension.
+	Format Format
+	Header http.Header
+	// Timeout and PollInterval default to 10s and 1m when not positive.
+	Timeout      time.Duration
+	PollInterval time.Duration
+	Client       *http.Client
+
+	mu     sync.Mutex
+	etag   string
+	cached map[string]string
+}
+
+const (
+	defaultHTTPTimeout      = 10 * time.Second
+	defaultHTTPPollInterval = time.Minute
+)
+
+func NewHTTPSource(rawURL string, format Format) *HTTPSource {
+	return &HTTPSource{
+		URL:          rawURL,
+		Format:       format,
+		Header:       make(http.Header),
+		Timeout:      defaultHTTPTimeout,
+		PollInterval: defaultHTTPPollInterval,
+	}
+}
+
+func (s *HTTPSource) WithBearerToken(token string) *HTTPSource {
+	if s.Header == nil {
+		s.Header = make(http.Header)
+	}
+	s.Header.Set("Authorization", "Bearer "+token)
+	return s
+}
+
+func (s *HTTPSource) Load() (map[string]string, error) {
+	values, _, err := s.fetch(context.Background())
+	return values, err
+}
+
+func (s *HTTPSource) Watch(ctx context.Context, changed func()) error {
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, modified, err := s.fetch(ctx)
+			if err != nil {
+				logWatchError("HTTP", s.URL, err)
+				continue
+			}
+			if modified {
+				changed()
+			}
+		}
+	}
+}
+
+func (s *HTTPSource) fetch(ctx context.Context) (map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid config URL: %w", err)
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("error fetching config from %s: %w", redactURL(s.URL), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && s.cached != nil {
+		return copyEnvs(s.cached), false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("error fetching config from %s: unexpected status %s", redactURL(s.URL), resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading config from %s: %w", redactURL(s.URL), err)
+	}
+
+	values, err := decodeFormat(s.formatFor(resp), data)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing config from %s: %w", redactURL(s.URL), err)
+	}
+
+	// Without an ETag every poll downloads the document again; only a
+	// change in its values counts as a modification.
+	modified := s.cached == nil || !maps.Equal(s.cached, values)
+	s.etag = resp.Header.Get("ETag")
+	s.cached = values
+	return copyEnvs(values), modified, nil
+}
+
+func (s *HTTPSource) client() *http.Client {
+	if s.Client != nil {
+		return s.Client
+	}
+	return http.DefaultClient
+}
+
+func (s *HTTPSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultHTTPTimeout)
+}
+
+func (s *HTTPSource) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultHTTPPollInterval)
+}
+
+func (s *HTTPSource) formatFor(resp *http.Response) Format {
+	if s.Format != "" {
+		return s.Format
+	}
+	if format, ok := formatForContentType(resp.Header.Get("Content-Type")); ok {
+		return format
+	}
+	if u, err := url.Parse(s.URL); err == nil {
+		return formatForFile(path.Base(u.Path))
+	}
+	return FormatDotenv
+}
+
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+func formatForContentType(contentType string) (Format, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case "application/json":
+		return FormatJSON, true
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return FormatYAML, true
+	case "application/toml":
+		return FormatTOML, true
+	case "application/xml", "text/xml":
+		return FormatXML, true
+	}
+	return "", false
+}
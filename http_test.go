@@ -0,0 +1,86 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPSourceZeroValue(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q, want Bearer token", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"port": 8080}`))
+	}))
+	defer srv.Close()
+
+	s := (&HTTPSource{URL: srv.URL}).WithBearerToken("token")
+	if got := s.timeout(); got != defaultHTTPTimeout {
+		t.Errorf("timeout = %v, want %v", got, defaultHTTPTimeout)
+	}
+	if got := s.pollInterval(); got != defaultHTTPPollInterval {
+		t.Errorf("pollInterval = %v, want %v", got, defaultHTTPPollInterval)
+	}
+	values, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := map[string]string{"PORT": "8080"}; !maps.Equal(values, want) {
+		t.Errorf("Load = %v, want %v", values, want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Watch(ctx, func() {}); err != nil {
+		t.Errorf("Watch = %v", err)
+	}
+}
+
+func TestHTTPSourceChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		etag   bool
+		bodies []string
+		want   []bool
+	}{
+		{"same body without ETag", false, []string{`{"a": 1}`, `{"a": 1}`, `{"a": 1}`}, []bool{true, false, false}},
+		{"changed body without ETag", false, []string{`{"a": 1}`, `{"a": 2}`, `{"a": 2}`}, []bool{true, true, false}},
+		{"not modified with ETag", true, []string{`{"a": 1}`, `{"a": 1}`}, []bool{true, false}},
+		{"new ETag with the same values", true, []string{`{"a": 1}`, `{"a":1}`}, []bool{true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body := tt.bodies[requests.Add(1)-1]
+				if tt.etag {
+					etag := `"` + body + `"`
+					if r.Header.Get("If-None-Match") == etag {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+					w.Header().Set("ETag", etag)
+				}
+				w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			s := NewHTTPSource(srv.URL, FormatJSON)
+			for i, want := range tt.want {
+				_, modified, err := s.fetch(context.Background())
+				if err != nil {
+					t.Fatalf("fetch #%d: %v", i+1, err)
+				}
+				if modified != want {
+					t.Errorf("fetch #%d modified = %v, want %v", i+1, modified, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// includeKey lists the files a config file includes, comma-separated, e.g.
+// CONFIG_INCLUDE=/etc/app/common.env. Structured files may use a top-level
+// include list instead. Included files are loaded first, in order, so the
+// including file overrides them; relative paths are resolved against the
+// including file's directory.
+const includeKey = "CONFIG_INCLUDE"
+
+// decodeFile decodes a config file and merges in the files it includes
+// with overlay. stack holds the files including it, to detect cycles.
+func decodeFile(kind, file string, data []byte, decode decodeFunc, stack []string, overlay overlayFunc) (map[string]string, error) {
+	doc, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s file %s: %w", kind, file, err)
+	}
+	envs := toEnvs(doc)
+	if _, flat := doc.(map[string]string); !flat {
+		if include, ok := envs["INCLUDE"]; ok {
+			delete(envs, "INCLUDE")
+			if envs[includeKey] != "" {
+				include += "," + envs[includeKey]
+			}
+			envs[includeKey] = include
+		}
+	}
+	return includeFiles(file, envs, stack, overlay)
+}
+
+// readEnvFile reads a .env file and the files it includes. Unless verbatim
+// is set, godotenv expands $VAR and ${VAR} in values itself; WithInterpolation
+// reads the file verbatim to expand references with its own rules.
+func readEnvFile(file string, verbatim bool, overlay overlayFunc) (map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if verbatim {
+		data = bytes.ReplaceAll(data, []byte("$"), []byte(dollarPlaceholder))
+	}
+	envs, err := godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if verbatim {
+		for key, value := range envs {
+			envs[key] = strings.ReplaceAll(value, dollarPlaceholder, "$")
+		}
+	}
+	return includeFiles(file, envs, nil, overlay)
+}
+
+// dollarPlaceholder hides $ from godotenv's expansion.
+const dollarPlaceholder = "\x00"
+
+// includeFiles returns envs over the files named by its CONFIG_INCLUDE key.
+func includeFiles(file string, envs map[string]string, stack []string, overlay overlayFunc) (map[string]string, error) {
+	includes, ok := envs[includeKey]
+	if !ok {
+		return envs, nil
+	}
+	delete(envs, includeKey)
+
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving includes of %s: %w", file, err)
+	}
+	stack = append(stack, canonicalPath(path))
+
+	merged := make(map[string]string)
+	for _, include := range strings.Split(includes, ",") {
+		if include = strings.TrimSpace(include); include == "" {
+			continue
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		include = canonicalPath(include)
+		if slices.Contains(stack, include) {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), include)
+		}
+
+		data, err := os.ReadFile(include)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file included by %s: %w", file, err)
+		}
+		f, err := lookupFormat(formatForFile(include))
+		if err != nil {
+			return nil, err
+		}
+		values, err := decodeFile(f.kind, include, data, f.decode, stack, overlay)
+		if err != nil {
+			return nil, err
+		}
+		overlay(merged, values)
+	}
+	overlay(merged, envs)
+	return merged, nil
+}
+
+// canonicalPath cleans an absolute path and resolves its symlinks, so that
+// cycles are found however a file is named. Paths that cannot be resolved,
+// such as missing files, are only cleaned.
+func canonicalPath(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
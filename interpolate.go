@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithInterpolation expands references to other keys inside values, as
+// envsubst would, so DATABASE_URL=postgres://${DB_USER}:${DB_PASS}@${DB_HOST}/app
+// is assembled at load time. References resolve with the usual precedence,
+// down to the OS environment and defaults. ${KEY:-fallback} uses fallback
+// when KEY is unset or empty, ${KEY:?message} fails the load instead, and $$
+// stands for a literal $. OS environment variables and values read through
+// KEY_FILE are used verbatim.
+func WithInterpolation() Option {
+	return func(c *Config) {
+		c.interpolate = true
+	}
+}
+
+type interpolator struct {
+	env            *environment
+	envs, defaults map[string]string
+	expanded       map[string]string
+	// active holds the keys being expanded, in order, to report cycles.
+	active []string
+}
+
+// interpolateEnvs replaces every value holding references with its
+// expansion, in the layer it came from. Only the values of sources and
+// defaults are expanded: OS environment variables belong to other programs
+// as much as to this one, so they are used verbatim, and only when
+// referenced.
+func interpolateEnvs(env *environment, envs, defaults map[string]string) error {
+	in := &interpolator{env: env, envs: envs, defaults: defaults, expanded: make(map[string]string)}
+
+	var keys []string
+	for key := range envs {
+		keys = append(keys, key)
+	}
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		if _, fromFile := lookupFileEnv(env, envs, key); fromFile {
+			continue
+		}
+		raw := getEnvWithFallback(env, envs, key, "")
+		layer := envs
+		if raw == "" {
+			raw, layer = defaults[key], defaults
+		}
+		if !strings.Contains(raw, "$") || fromEnvironment(env, key, raw) {
+			continue
+		}
+		value, err := in.value(key)
+		if err != nil {
+			return err
+		}
+		layer[key] = value
+	}
+	return nil
+}
+
+func (in *interpolator) value(key string) (string, error) {
+	if value, ok := in.expanded[key]; ok {
+		return value, nil
+	}
+	if value, fromFile := lookupFileEnv(in.env, in.envs, key); fromFile {
+		return value, nil
+	}
+	for i, active := range in.active {
+		if active == key {
+			return "", fmt.Errorf("interpolation cycle: %s -> %s", strings.Join(in.active[i:], " -> "), key)
+		}
+	}
+
+	raw := getEnvWithFallback(in.env, in.envs, key, in.defaults[key])
+	if fromEnvironment(in.env, key, raw) {
+		return raw, nil
+	}
+	in.active = append(in.active, key)
+	value, err := in.expand(raw, key)
+	in.active = in.active[:len(in.active)-1]
+	if err != nil {
+		return "", err
+	}
+	in.expanded[key] = value
+	return value, nil
+}
+
+// fromEnvironment reports whether value, resolved for key, is that of the
+// OS environment variable, which envs also holds under a custom precedence.
+func fromEnvironment(env *environment, key, value string) bool {
+	osValue, exists := env.lookup(key)
+	return exists && osValue == value
+}
+
+// expand replaces the references in s, the value of key.
+func (in *interpolator) expand(s, key string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '{':
+		default:
+			b.WriteByte('$')
+			continue
+		}
+
+		end, depth := -1, 0
+		for j := i + 1; j < len(s) && end < 0; j++ {
+			switch s[j] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = j
+				}
+			}
+		}
+		if end < 0 {
+			return "", fmt.Errorf("invalid value for %s: unterminated ${ in %q", key, s)
+		}
+
+		ref := s[i+2 : end]
+		name, fallback, hasFallback := strings.Cut(ref, ":-")
+		name, message, required := strings.Cut(name, ":?")
+		value, err := in.value(name)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case value != "":
+		case required:
+			if message == "" {
+				message = "is not set"
+			}
+			return "", fmt.Errorf("invalid value for %s: %s %s", key, name, message)
+		case hasFallback:
+			if value, err = in.expand(fallback, key); err != nil {
+				return "", err
+			}
+		}
+		b.WriteString(value)
+		i = end
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// WithIPKeys declares keys holding an IP address, such as BIND_ADDR, so
+// NewConfig rejects malformed values instead of leaving them to fail later.
+func WithIPKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parseIP(value)
+		return err
+	})
+}
+
+// WithCIDRKeys declares keys holding a list of CIDR prefixes, such as
+// TRUSTED_PROXIES, to be validated by NewConfig.
+func WithCIDRKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := listParser(c, parsePrefix)(value)
+		return err
+	})
+}
+
+func (c *Config) GetIP(key string) (net.IP, error) {
+	return getValue(c, key, parseIP)
+}
+
+func (c *Config) GetIPWithFallback(key string, fallback net.IP) net.IP {
+	return getValueWithFallback(c, key, "IP address", fallback, parseIP)
+}
+
+// GetPrefix parses a CIDR prefix. A bare address is read as a single-host
+// prefix, e.g. 10.0.0.1 as 10.0.0.1/32.
+func (c *Config) GetPrefix(key string) (netip.Prefix, error) {
+	return getValue(c, key, parsePrefix)
+}
+
+func (c *Config) GetPrefixWithFallback(key string, fallback netip.Prefix) netip.Prefix {
+	return getValueWithFallback(c, key, "CIDR", fallback, parsePrefix)
+}
+
+func (c *Config) GetPrefixes(key string) ([]netip.Prefix, error) {
+	return getValue(c, key, listParser(c, parsePrefix))
+}
+
+func (c *Config) GetPrefixesWithFallback(key string, fallback []netip.Prefix) []netip.Prefix {
+	return getValueWithFallback(c, key, "CIDR list", fallback, listParser(c, parsePrefix))
+}
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("%q is not an IP address", s)
+	}
+	return ip, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%q is not a CIDR prefix or IP address", s)
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is not a CIDR prefix", s)
+	}
+	return prefix, nil
+}
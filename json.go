@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+func WithJSONFile(file string) Option {
+	return withFile("JSON", file, decodeJSON)
+}
+
+func WithJSONReader(r io.Reader) Option {
+	return withReader("JSON", r, decodeJSON)
+}
+
+func decodeJSON(data []byte) (any, error) {
+	var doc map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// WithJSONKeys declares keys holding a JSON document, such as
+// RATE_LIMITS='{"default":100}', so NewConfig rejects malformed ones.
+func WithJSONKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		if !json.Valid([]byte(value)) {
+			return errors.New("value is not valid JSON")
+		}
+		return nil
+	})
+}
+
+// GetJSON unmarshals the JSON value of key into v.
+func (c *Config) GetJSON(key string, v any) error {
+	value := c.Get(key)
+	if value == "" {
+		return fmt.Errorf("%s is not set", key)
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return nil
+}
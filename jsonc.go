@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"io"
+)
+
+func WithJSONCFile(file string) Option {
+	return withFile("JSONC", file, decodeJSONC)
+}
+
+func WithJSONCReader(r io.Reader) Option {
+	return withReader("JSONC", r, decodeJSONC)
+}
+
+func decodeJSONC(data []byte) (any, error) {
+	stripped, err := stripJSONC(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(stripped)
+}
+
+// stripJSONC removes // and /* */ comments and trailing commas before a
+// closing bracket, leaving string literals untouched.
+func stripJSONC(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	pendingComma := -1
+
+	for i := 0; i < len(data); i++ {
+		ch := data[i]
+		switch {
+		case ch == '"':
+			start := i
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(data) {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			pendingComma = -1
+			out = append(out, data[start:i+1]...)
+		case ch == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case ch == '/' && i+1 < len(data) && data[i+1] == '*':
+			start := i
+			for i += 2; i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/'); i++ {
+			}
+			if i+1 >= len(data) {
+				return nil, fmt.Errorf("unterminated comment at offset %d", start)
+			}
+			i++
+			out = append(out, ' ')
+		case ch == ',':
+			pendingComma = len(out)
+			out = append(out, ch)
+		case ch == '}' || ch == ']':
+			if pendingComma >= 0 {
+				out = append(out[:pendingComma], out[pendingComma+1:]...)
+				pendingComma = -1
+			}
+			out = append(out, ch)
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			out = append(out, ch)
+		default:
+			pendingComma = -1
+			out = append(out, ch)
+		}
+	}
+	return out, nil
+}
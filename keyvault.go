@@ -0,0 +1,84 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	keyVaultAPIVersion     = "7.4"
+	defaultKeyVaultTimeout = 30 * time.Second
+)
+
+// KeyVaultSource loads the enabled secrets of an Azure Key Vault using
+// managed identity. Secret names map onto keys with dashes turned into
+// underscores, so DATABASE-URL becomes DATABASE_URL.
+type KeyVaultSource struct {
+	// VaultURL is the vault's URL, e.g. https://myapp.vault.azure.net.
+	VaultURL string
+	// ClientID selects a user-assigned identity; AZURE_CLIENT_ID when empty.
+	ClientID string
+	// Timeout defaults to 30s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	once sync.Once
+	cred *azureCredential
+}
+
+func NewKeyVaultSource(vaultURL string) *KeyVaultSource {
+	return &KeyVaultSource{
+		VaultURL: strings.TrimSuffix(vaultURL, "/"),
+		Timeout:  defaultKeyVaultTimeout,
+	}
+}
+
+func (s *KeyVaultSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	s.once.Do(func() { s.cred = newAzureCredential("https://vault.azure.net", s.ClientID, s.Client) })
+
+	envs := make(map[string]string)
+	next := s.VaultURL + "/secrets?api-version=" + keyVaultAPIVersion
+	for next != "" {
+		var page struct {
+			Value []struct {
+				ID         string `json:"id"`
+				Attributes struct {
+					Enabled bool `json:"enabled"`
+				} `json:"attributes"`
+				ContentType string `json:"contentType"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := s.cred.get(ctx, next, &page); err != nil {
+			return nil, fmt.Errorf("error listing Key Vault secrets: %w", err)
+		}
+
+		for _, item := range page.Value {
+			if !item.Attributes.Enabled {
+				continue
+			}
+			name := path.Base(item.ID)
+			var secret struct {
+				Value string `json:"value"`
+			}
+			if err := s.cred.get(ctx, s.VaultURL+"/secrets/"+name+"?api-version="+keyVaultAPIVersion, &secret); err != nil {
+				return nil, fmt.Errorf("error reading Key Vault secret %s: %w", name, err)
+			}
+			envs[normalizeKey(name)] = secret.Value
+		}
+		next = page.NextLink
+	}
+	return envs, nil
+}
+
+func (s *KeyVaultSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultKeyVaultTimeout)
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesSource reads a ConfigMap or Secret through the Kubernetes API
+// using the pod's service account. Watching it streams changes so values
+// can be reloaded without a restart.
+type KubernetesSource struct {
+	Namespace string
+	Name      string
+	// APIServer is the API base URL. In-cluster settings are used when
+	// empty.
+	APIServer string
+	TokenFile string
+	// Timeout defaults to 10s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	resource string
+	once     sync.Once
+	initErr  error
+}
+
+const defaultKubernetesTimeout = 10 * time.Second
+
+func NewConfigMapSource(namespace, name string) *KubernetesSource {
+	return newKubernetesSource("configmaps", namespace, name)
+}
+
+func NewSecretSource(namespace, name string) *KubernetesSource {
+	return newKubernetesSource("secrets", namespace, name)
+}
+
+func newKubernetesSource(resource, namespace, name string) *KubernetesSource {
+	return &KubernetesSource{
+		Namespace: namespace,
+		Name:      name,
+		TokenFile: serviceAccountDir + "/token",
+		Timeout:   defaultKubernetesTimeout,
+		resource:  resource,
+	}
+}
+
+type kubernetesObject struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Data       map[string]string `json:"data"`
+	BinaryData map[string]string `json:"binaryData"`
+}
+
+func (s *KubernetesSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	obj, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.values(obj)
+}
+
+func (s *KubernetesSource) Watch(ctx context.Context, changed func()) error {
+	var resourceVersion string
+	for {
+		if resourceVersion == "" {
+			obj, err := s.get(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				logWatchError("Kubernetes", s.Name, err)
+				if !sleepContext(ctx, 5*time.Second) {
+					return nil
+				}
+				continue
+			}
+			resourceVersion = obj.Metadata.ResourceVersion
+		}
+
+		next, err := s.watchOnce(ctx, resourceVersion, changed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logWatchError("Kubernetes", s.Name, err)
+			if !sleepContext(ctx, 5*time.Second) {
+				return nil
+			}
+		}
+		resourceVersion = next
+	}
+}
+
+func (s *KubernetesSource) watchOnce(ctx context.Context, resourceVersion string, changed func()) (string, error) {
+	query := url.Values{
+		"watch":           {"true"},
+		"fieldSelector":   {"metadata.name=" + s.Name},
+		"resourceVersion": {resourceVersion},
+	}
+	req, err := s.request(ctx, fmt.Sprintf("/api/v1/namespaces/%s/%s?%s", url.PathEscape(s.namespace()), s.resource, query.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return resourceVersion, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s watching %s/%s", resp.Status, s.resource, s.Name)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var event struct {
+			Type   string           `json:"type"`
+			Object kubernetesObject `json:"object"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return resourceVersion, fmt.Errorf("invalid watch event: %w", err)
+		}
+		switch event.Type {
+		case "ADDED", "MODIFIED", "DELETED":
+			resourceVersion = event.Object.Metadata.ResourceVersion
+			changed()
+		case "ERROR":
+			// Usually 410 Gone: the resource version expired, start over.
+			return "", nil
+		}
+	}
+	return resourceVersion, scanner.Err()
+}
+
+func (s *KubernetesSource) get(ctx context.Context) (*kubernetesObject, error) {
+	req, err := s.request(ctx, fmt.Sprintf("/api/v1/namespaces/%s/%s/%s", url.PathEscape(s.namespace()), s.resource, url.PathEscape(s.Name)))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s/%s: %w", s.resource, s.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching %s/%s: unexpected status %s", s.resource, s.Name, resp.Status)
+	}
+
+	var obj kubernetesObject
+	if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+		return nil, fmt.Errorf("error decoding %s/%s: %w", s.resource, s.Name, err)
+	}
+	return &obj, nil
+}
+
+func (s *KubernetesSource) values(obj *kubernetesObject) (map[string]string, error) {
+	envs := make(map[string]string, len(obj.Data)+len(obj.BinaryData))
+	for key, value := range obj.BinaryData {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binary data for %s in %s/%s: %w", key, s.resource, s.Name, err)
+		}
+		envs[key] = string(decoded)
+	}
+	for key, value := range obj.Data {
+		if s.resource == "secrets" {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid data for %s in %s/%s: %w", key, s.resource, s.Name, err)
+			}
+			value = string(decoded)
+		}
+		envs[key] = value
+	}
+	return envs, nil
+}
+
+func (s *KubernetesSource) request(ctx context.Context, path string) (*http.Request, error) {
+	s.once.Do(func() { s.initErr = s.init() })
+	if s.initErr != nil {
+		return nil, s.initErr
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.APIServer, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// Projected service account tokens rotate, so read it on every request.
+	if token, err := os.ReadFile(s.TokenFile); err == nil {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading service account token: %w", err)
+	}
+	return req, nil
+}
+
+func (s *KubernetesSource) init() error {
+	if s.APIServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return errors.New("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST is not set")
+		}
+		s.APIServer = "https://" + net.JoinHostPort(host, port)
+	}
+	if s.Client != nil {
+		return nil
+	}
+
+	ca, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		if os.IsNotExist(err) {
+			s.Client = http.DefaultClient
+			return nil
+		}
+		return fmt.Errorf("error reading cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return errors.New("invalid cluster CA certificate")
+	}
+	s.Client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+	return nil
+}
+
+func (s *KubernetesSource) namespace() string {
+	if s.Namespace != "" {
+		return s.Namespace
+	}
+	if ns, err := os.ReadFile(serviceAccountDir + "/namespace"); err == nil {
+		return strings.TrimSpace(string(ns))
+	}
+	return "default"
+}
+
+func (s *KubernetesSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultKubernetesTimeout)
+}
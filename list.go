@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// WithListSeparator changes the separator used by the list getters from a
+// comma, e.g. to ";" for values that contain commas themselves.
+func WithListSeparator(sep string) Option {
+	return func(c *Config) {
+		if sep != "" {
+			c.listSeparator = sep
+		}
+	}
+}
+
+// GetStrings splits a list such as ALLOWED_ORIGINS=a.com, b.com into its
+// trimmed, non-empty items. It returns nil when the key is not set.
+func (c *Config) GetStrings(key string) []string {
+	return c.splitList(c.Get(key))
+}
+
+func (c *Config) GetStringsWithFallback(key string, fallback []string) []string {
+	if items := c.GetStrings(key); len(items) > 0 {
+		return items
+	}
+	return fallback
+}
+
+func (c *Config) GetInts(key string) ([]int, error) {
+	return getValue(c, key, listParser(c, strconv.Atoi))
+}
+
+func (c *Config) GetIntsWithFallback(key string, fallback []int) []int {
+	return getValueWithFallback(c, key, "integer list", fallback, listParser(c, strconv.Atoi))
+}
+
+func (c *Config) GetURLs(key string) ([]*url.URL, error) {
+	return getValue(c, key, listParser(c, parseURL))
+}
+
+func (c *Config) GetURLsWithFallback(key string, fallback []*url.URL) []*url.URL {
+	return getValueWithFallback(c, key, "URL list", fallback, listParser(c, parseURL))
+}
+
+// GetStringMap parses pairs such as FEATURE_WEIGHTS=search:10,feed:3. Keys
+// and values are separated by the first ":" or "=".
+func (c *Config) GetStringMap(key string) (map[string]string, error) {
+	return getValue(c, key, mapParser(c, parseString))
+}
+
+func (c *Config) GetStringMapWithFallback(key string, fallback map[string]string) map[string]string {
+	return getValueWithFallback(c, key, "map", fallback, mapParser(c, parseString))
+}
+
+func (c *Config) GetIntMap(key string) (map[string]int, error) {
+	return getValue(c, key, mapParser(c, strconv.Atoi))
+}
+
+func (c *Config) GetIntMapWithFallback(key string, fallback map[string]int) map[string]int {
+	return getValueWithFallback(c, key, "integer map", fallback, mapParser(c, strconv.Atoi))
+}
+
+func (c *Config) splitList(s string) []string {
+	sep := c.listSeparator
+	if sep == "" {
+		sep = ","
+	}
+
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func listParser[T any](c *Config, parse func(string) (T, error)) func(string) ([]T, error) {
+	return func(s string) ([]T, error) {
+		items := c.splitList(s)
+		values := make([]T, 0, len(items))
+		for i, item := range items {
+			value, err := parse(item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	}
+}
+
+func mapParser[T any](c *Config, parse func(string) (T, error)) func(string) (map[string]T, error) {
+	return func(s string) (map[string]T, error) {
+		values := make(map[string]T)
+		for _, item := range c.splitList(s) {
+			i := strings.IndexAny(item, ":=")
+			if i <= 0 {
+				return nil, fmt.Errorf("%q is not a key:value pair", item)
+			}
+			name := strings.TrimSpace(item[:i])
+			value, err := parse(strings.TrimSpace(item[i+1:]))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			values[name] = value
+		}
+		return values, nil
+	}
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Live holds the current Config of a watched configuration and swaps it
+// atomically whenever a source reports a change.
+type Live struct {
+	current atomic.Pointer[Config]
+}
+
+func NewLive(opts ...Option) (*Live, error) {
+	c, err := NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	l := &Live{}
+	l.current.Store(c)
+	return l, nil
+}
+
+func (l *Live) Current() *Config {
+	return l.current.Load()
+}
+
+func (l *Live) Watch(ctx context.Context) error {
+	return l.Current().Watch(ctx, func(fresh *Config) {
+		l.current.Store(fresh)
+	})
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+func WithLogLevel(level slog.Level) Option {
+	return func(c *Config) {
+		c.LogLevel = level
+		c.setExplicit("LOG_LEVEL")
+	}
+}
+
+func (c *Config) GetLogLevel(key string) (slog.Level, error) {
+	return getValue(c, key, parseLogLevel)
+}
+
+func (c *Config) GetLogLevelWithFallback(key string, fallback slog.Level) slog.Level {
+	return getValueWithFallback(c, key, "log level", fallback, parseLogLevel)
+}
+
+// ZapLevel maps a slog level onto zapcore.Level, for zapcore.Level(ZapLevel(l)).
+func ZapLevel(level slog.Level) int8 {
+	switch {
+	case level < slog.LevelInfo:
+		return -1
+	case level < slog.LevelWarn:
+		return 0
+	case level < slog.LevelError:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// ZerologLevel maps a slog level onto zerolog.Level, for
+// zerolog.Level(ZerologLevel(l)). Levels below debug map to trace.
+func ZerologLevel(level slog.Level) int8 {
+	switch {
+	case level < slog.LevelDebug:
+		return -1
+	case level < slog.LevelInfo:
+		return 0
+	case level < slog.LevelWarn:
+		return 1
+	case level < slog.LevelError:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// parseLogLevel accepts the slog names in any case (debug, info, warn,
+// error, optionally with an offset such as warn+2) and "warning".
+func parseLogLevel(s string) (slog.Level, error) {
+	if strings.EqualFold(s, "warning") {
+		s = "warn"
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("%q is not a log level (use debug, info, warn or error)", s)
+	}
+	return level, nil
+}
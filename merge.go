@@ -0,0 +1,113 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WithAppendLists makes later sources extend the given lists instead of
+// replacing them: a scalar list such as ALLOWED_ORIGINS gets the later
+// items after the earlier ones, and a list of tables such as UPSTREAM gets
+// the later entries renumbered after the earlier ones.
+func WithAppendLists(keys ...string) Option {
+	return func(c *Config) {
+		if c.appendLists == nil {
+			c.appendLists = make(map[string]bool)
+		}
+		for _, key := range keys {
+			c.appendLists[key] = true
+		}
+	}
+}
+
+// overlay merges src over dst with the rules of overlayEnvs and the lists
+// declared with WithAppendLists.
+func (c *Config) overlay(dst, src map[string]string) {
+	sep := c.listSeparator
+	if sep == "" {
+		sep = ","
+	}
+	overlayEnvs(dst, src, c.appendLists, sep)
+}
+
+// overlayFunc merges src over dst, as overlay does.
+type overlayFunc func(dst, src map[string]string)
+
+// defaultOverlay is overlay for sources built outside a Config, which
+// replace lists.
+func defaultOverlay(dst, src map[string]string) {
+	overlayEnvs(dst, src, nil, ",")
+}
+
+// overlayEnvs merges src over dst the way structured documents deep-merge:
+// nested keys, already flattened, override one by one, while a list of
+// tables replaces the earlier list as a whole, so that an overlay with one
+// UPSTREAM entry does not keep UPSTREAM_1_URL from the base. A list of
+// tables is recognised by an UPSTREAM_0_ key, as in binding. Lists in
+// appendKeys are extended instead, scalar lists being joined with sep.
+func overlayEnvs(dst, src map[string]string, appendKeys map[string]bool, sep string) {
+	lists := make(map[string]bool)
+	for key := range src {
+		for i := strings.Index(key, "_0_"); i > 0; {
+			lists[key[:i]] = true
+			next := strings.Index(key[i+1:], "_0_")
+			if next < 0 {
+				break
+			}
+			i += next + 1
+		}
+	}
+
+	for list := range lists {
+		for outer := range lists {
+			if _, nested := listIndex(list, outer); nested {
+				delete(lists, list)
+			}
+		}
+	}
+
+	offsets := make(map[string]int)
+	for list := range lists {
+		for key := range dst {
+			i, ok := listIndex(key, list)
+			switch {
+			case !ok:
+			case !appendKeys[list]:
+				delete(dst, key)
+			case i >= offsets[list]:
+				offsets[list] = i + 1
+			}
+		}
+	}
+
+	for key, value := range src {
+		for list, offset := range offsets {
+			if i, ok := listIndex(key, list); ok {
+				key = list + "_" + strconv.Itoa(i+offset) + key[len(list)+1+len(strconv.Itoa(i)):]
+				break
+			}
+		}
+		if appendKeys[key] && value != "" && dst[key] != "" {
+			value = dst[key] + sep + value
+		}
+		dst[key] = value
+	}
+}
+
+// listIndex reports the index of an item of list named by key, so
+// UPSTREAM_2_URL is item 2 of UPSTREAM.
+func listIndex(key, list string) (int, bool) {
+	rest, ok := strings.CutPrefix(key, list+"_")
+	if !ok {
+		return 0, false
+	}
+	digits, _, found := strings.Cut(rest, "_")
+	if !found {
+		return 0, false
+	}
+	i, err := strconv.Atoi(digits)
+	if err != nil || i < 0 || strconv.Itoa(i) != digits {
+		return 0, false
+	}
+	return i, true
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOverlayEnvs(t *testing.T) {
+	tests := []struct {
+		name       string
+		dst, src   map[string]string
+		appendKeys []string
+		sep        string
+		want       map[string]string
+	}{
+		{
+			name: "scalars override one by one",
+			dst:  map[string]string{"DATABASE_URL": "postgres://a", "DATABASE_POOL": "5"},
+			src:  map[string]string{"DATABASE_POOL": "10", "PORT": "8080"},
+			want: map[string]string{"DATABASE_URL": "postgres://a", "DATABASE_POOL": "10", "PORT": "8080"},
+		},
+		{
+			name: "list of tables replaced as a whole",
+			dst:  map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_0_WEIGHT": "1", "UPSTREAM_1_URL": "b", "UPSTREAM_TIMEOUT": "5s"},
+			src:  map[string]string{"UPSTREAM_0_URL": "c"},
+			want: map[string]string{"UPSTREAM_0_URL": "c", "UPSTREAM_TIMEOUT": "5s"},
+		},
+		{
+			name: "list untouched by the overlay is kept",
+			dst:  map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_1_URL": "b"},
+			src:  map[string]string{"UPSTREAM_TIMEOUT": "5s"},
+			want: map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_1_URL": "b", "UPSTREAM_TIMEOUT": "5s"},
+		},
+		{
+			name: "nested list belongs to the outer list",
+			dst:  map[string]string{"UPSTREAM_0_HEADERS_0_NAME": "a", "UPSTREAM_0_HEADERS_1_NAME": "b", "UPSTREAM_1_URL": "x"},
+			src:  map[string]string{"UPSTREAM_0_HEADERS_0_NAME": "c"},
+			want: map[string]string{"UPSTREAM_0_HEADERS_0_NAME": "c"},
+		},
+		{
+			name:       "list of tables appended",
+			dst:        map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_1_URL": "b", "UPSTREAM_1_WEIGHT": "2"},
+			src:        map[string]string{"UPSTREAM_0_URL": "c", "UPSTREAM_1_URL": "d"},
+			appendKeys: []string{"UPSTREAM"},
+			want:       map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_1_URL": "b", "UPSTREAM_1_WEIGHT": "2", "UPSTREAM_2_URL": "c", "UPSTREAM_3_URL": "d"},
+		},
+		{
+			name:       "appended list with gaps continues after the highest index",
+			dst:        map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_4_URL": "b"},
+			src:        map[string]string{"UPSTREAM_0_URL": "c"},
+			appendKeys: []string{"UPSTREAM"},
+			want:       map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_4_URL": "b", "UPSTREAM_5_URL": "c"},
+		},
+		{
+			name:       "appended list starting empty",
+			dst:        map[string]string{"PORT": "80"},
+			src:        map[string]string{"UPSTREAM_0_URL": "c"},
+			appendKeys: []string{"UPSTREAM"},
+			want:       map[string]string{"PORT": "80", "UPSTREAM_0_URL": "c"},
+		},
+		{
+			name:       "only declared lists are appended",
+			dst:        map[string]string{"UPSTREAM_0_URL": "a", "BACKEND_0_URL": "x", "BACKEND_1_URL": "y"},
+			src:        map[string]string{"UPSTREAM_0_URL": "b", "BACKEND_0_URL": "z"},
+			appendKeys: []string{"UPSTREAM"},
+			want:       map[string]string{"UPSTREAM_0_URL": "a", "UPSTREAM_1_URL": "b", "BACKEND_0_URL": "z"},
+		},
+		{
+			name:       "scalar list joined",
+			dst:        map[string]string{"ALLOWED_ORIGINS": "https://a,https://b"},
+			src:        map[string]string{"ALLOWED_ORIGINS": "https://c"},
+			appendKeys: []string{"ALLOWED_ORIGINS"},
+			sep:        ",",
+			want:       map[string]string{"ALLOWED_ORIGINS": "https://a,https://b,https://c"},
+		},
+		{
+			name:       "scalar list with custom separator",
+			dst:        map[string]string{"PATHS": "/a"},
+			src:        map[string]string{"PATHS": "/b"},
+			appendKeys: []string{"PATHS"},
+			sep:        ":",
+			want:       map[string]string{"PATHS": "/a:/b"},
+		},
+		{
+			name:       "scalar list set for the first time",
+			dst:        map[string]string{},
+			src:        map[string]string{"ALLOWED_ORIGINS": "https://c"},
+			appendKeys: []string{"ALLOWED_ORIGINS"},
+			sep:        ",",
+			want:       map[string]string{"ALLOWED_ORIGINS": "https://c"},
+		},
+		{
+			name: "zero padded indexes are not items",
+			dst:  map[string]string{"UPSTREAM_00_URL": "a", "UPSTREAM_1_URL": "b"},
+			src:  map[string]string{"UPSTREAM_0_URL": "c"},
+			want: map[string]string{"UPSTREAM_00_URL": "a", "UPSTREAM_0_URL": "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appendKeys := make(map[string]bool)
+			for _, key := range tt.appendKeys {
+				appendKeys[key] = true
+			}
+			dst := maps.Clone(tt.dst)
+			overlayEnvs(dst, tt.src, appendKeys, tt.sep)
+			if !maps.Equal(dst, tt.want) {
+				t.Errorf("overlayEnvs =\n%v\nwant\n%v", dst, tt.want)
+			}
+		})
+	}
+}
+
+func TestListIndex(t *testing.T) {
+	tests := []struct {
+		key, list string
+		want      int
+		wantOK    bool
+	}{
+		{"UPSTREAM_0_URL", "UPSTREAM", 0, true},
+		{"UPSTREAM_12_URL", "UPSTREAM", 12, true},
+		{"UPSTREAM_0_HEADERS_1_NAME", "UPSTREAM_0_HEADERS", 1, true},
+		{"UPSTREAM_0", "UPSTREAM", 0, false},
+		{"UPSTREAM_TIMEOUT", "UPSTREAM", 0, false},
+		{"UPSTREAM_01_URL", "UPSTREAM", 0, false},
+		{"UPSTREAM_-1_URL", "UPSTREAM", 0, false},
+		{"UPSTREAMS_0_URL", "UPSTREAM", 0, false},
+		{"BACKEND_0_URL", "UPSTREAM", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			i, ok := listIndex(tt.key, tt.list)
+			if i != tt.want || ok != tt.wantOK {
+				t.Errorf("listIndex(%q, %q) = %d, %v, want %d, %v", tt.key, tt.list, i, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestWithAppendLists(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		t.Helper()
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	yamlFile := writeFile("config.yaml", "upstream:\n  - url: http://a\n  - url: http://b\nallowed_origins: https://a\n")
+	envFile := writeFile(".env", "UPSTREAM_0_URL=http://c\nALLOWED_ORIGINS=https://b\n")
+
+	tests := []struct {
+		name string
+		opts []Option
+		want map[string]string
+	}{
+		{
+			name: "replaced",
+			want: map[string]string{
+				"UPSTREAM_0_URL":  "http://c",
+				"UPSTREAM_1_URL":  "",
+				"ALLOWED_ORIGINS": "https://b",
+			},
+		},
+		{
+			name: "appended",
+			opts: []Option{WithAppendLists("UPSTREAM", "ALLOWED_ORIGINS")},
+			want: map[string]string{
+				"UPSTREAM_0_URL":  "http://a",
+				"UPSTREAM_1_URL":  "http://b",
+				"UPSTREAM_2_URL":  "http://c",
+				"ALLOWED_ORIGINS": "https://a,https://b",
+			},
+		},
+		{
+			name: "appended with separator",
+			opts: []Option{WithAppendLists("ALLOWED_ORIGINS"), WithListSeparator(";")},
+			want: map[string]string{"ALLOWED_ORIGINS": "https://a;https://b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithEnvFile(envFile), WithYAMLFile(yamlFile)}, tt.opts...)
+			c, err := LoadSnapshot(opts...)
+			if err != nil {
+				t.Fatalf("LoadSnapshot: %v", err)
+			}
+			for key, want := range tt.want {
+				if got := c.Get(key); got != want {
+					t.Errorf("Get(%q) = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ec2MetadataEndpoint    = "http://169.254.169.254"
+	gceMetadataEndpoint    = "http://metadata.google.internal"
+	defaultMetadataTimeout = 2 * time.Second
+)
+
+// MetadataSource reads instance metadata: EC2 instance tags through IMDSv2
+// (tags in metadata must be enabled on the instance) or GCE custom
+// attributes. Each tag or attribute becomes a key.
+type MetadataSource struct {
+	Endpoint string
+	// Timeout defaults to 2s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	provider string
+}
+
+func NewEC2MetadataSource() *MetadataSource {
+	return &MetadataSource{Endpoint: ec2MetadataEndpoint, Timeout: defaultMetadataTimeout, provider: "ec2"}
+}
+
+func NewGCEMetadataSource() *MetadataSource {
+	return &MetadataSource{Endpoint: gceMetadataEndpoint, Timeout: defaultMetadataTimeout, provider: "gce"}
+}
+
+func (s *MetadataSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	if s.provider == "gce" {
+		return s.loadGCE(ctx)
+	}
+	return s.loadEC2(ctx)
+}
+
+func (s *MetadataSource) loadEC2(ctx context.Context) (map[string]string, error) {
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, s.Endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := s.do(tokenReq)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting IMDSv2 token: %w", err)
+	}
+
+	get := func(path string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Endpoint+path, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		return s.do(req)
+	}
+
+	list, err := get("/latest/meta-data/tags/instance")
+	if err != nil {
+		return nil, fmt.Errorf("error listing instance tags: %w", err)
+	}
+
+	envs := make(map[string]string)
+	for _, tag := range strings.Fields(list) {
+		value, err := get("/latest/meta-data/tags/instance/" + tag)
+		if err != nil {
+			return nil, fmt.Errorf("error reading instance tag %s: %w", tag, err)
+		}
+		envs[tag] = value
+	}
+	return envs, nil
+}
+
+func (s *MetadataSource) loadGCE(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Endpoint+"/computeMetadata/v1/instance/attributes/?recursive=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	body, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error reading instance attributes: %w", err)
+	}
+
+	envs := make(map[string]string)
+	if err := json.Unmarshal([]byte(body), &envs); err != nil {
+		return nil, fmt.Errorf("error decoding instance attributes: %w", err)
+	}
+	return envs, nil
+}
+
+func (s *MetadataSource) do(req *http.Request) (string, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return string(body), nil
+}
+
+func (s *MetadataSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultMetadataTimeout)
+}
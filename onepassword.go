@@ -0,0 +1,135 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OnePasswordSource loads the fields of items in a vault through a
+// 1Password Connect server. Field labels become keys, so a field labelled
+// "database url" becomes DATABASE_URL. Vaults and items may be given by
+// name or ID.
+type OnePasswordSource struct {
+	Host  string
+	Token string
+	Vault string
+	Items []string
+	// Timeout defaults to 10s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+// NewOnePasswordSource defaults the host and token to OP_CONNECT_HOST and
+// OP_CONNECT_TOKEN, like the 1Password SDKs.
+const defaultOnePasswordTimeout = 10 * time.Second
+
+func NewOnePasswordSource(vault string, items ...string) *OnePasswordSource {
+	return &OnePasswordSource{
+		Host:    strings.TrimSuffix(os.Getenv("OP_CONNECT_HOST"), "/"),
+		Token:   os.Getenv("OP_CONNECT_TOKEN"),
+		Vault:   vault,
+		Items:   items,
+		Timeout: defaultOnePasswordTimeout,
+	}
+}
+
+var onePasswordID = regexp.MustCompile(`^[a-z0-9]{26}$`)
+
+func (s *OnePasswordSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	vault, err := s.resolve(ctx, "/v1/vaults", s.Vault)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving 1Password vault %s: %w", s.Vault, err)
+	}
+
+	envs := make(map[string]string)
+	for _, name := range s.Items {
+		item, err := s.resolve(ctx, "/v1/vaults/"+vault+"/items", name)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving 1Password item %s: %w", name, err)
+		}
+
+		var resp struct {
+			Fields []struct {
+				Label string `json:"label"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		}
+		if err := s.get(ctx, "/v1/vaults/"+vault+"/items/"+item, &resp); err != nil {
+			return nil, fmt.Errorf("error reading 1Password item %s: %w", name, err)
+		}
+		for _, field := range resp.Fields {
+			if field.Label != "" && field.Value != "" {
+				envs[normalizeKey(field.Label)] = field.Value
+			}
+		}
+	}
+	return envs, nil
+}
+
+// resolve maps a vault or item name to its ID using a Connect list filter.
+func (s *OnePasswordSource) resolve(ctx context.Context, collection, name string) (string, error) {
+	if onePasswordID.MatchString(name) {
+		return name, nil
+	}
+
+	field := "name"
+	if strings.HasSuffix(collection, "/items") {
+		field = "title"
+	}
+	filter := url.Values{"filter": {fmt.Sprintf("%s eq %q", field, name)}}
+	var matches []struct {
+		ID string `json:"id"`
+	}
+	if err := s.get(ctx, collection+"?"+filter.Encode(), &matches); err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.New("not found")
+	case 1:
+		return matches[0].ID, nil
+	default:
+		return "", fmt.Errorf("%d matches, use its ID instead", len(matches))
+	}
+}
+
+func (s *OnePasswordSource) get(ctx context.Context, path string, out any) error {
+	if s.Host == "" {
+		return errors.New("1Password Connect host is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.Host+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.Token)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (s *OnePasswordSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultOnePasswordTimeout)
+}
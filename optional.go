@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Optional holds a value that may not have been provided at all. Unlike
+// the fallback getters, which treat an empty value as unset, a key that is
+// present but empty gives Set == true and the zero Value.
+type Optional[T any] struct {
+	Value T
+	Set   bool
+}
+
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Set
+}
+
+// Or returns the value if it was provided and fallback otherwise.
+func (o Optional[T]) Or(fallback T) T {
+	if o.Set {
+		return o.Value
+	}
+	return fallback
+}
+
+// Lookup returns the value of key and whether it was provided by any
+// source, even if empty.
+func (c *Config) Lookup(key string) (string, bool) {
+	return c.lookup(c.resolveKey(key))
+}
+
+func (c *Config) lookup(key string) (string, bool) {
+	envs, defaults := c.resolvedEnvs()
+	if c.frozen() {
+		if value, exists := envs[key]; exists {
+			return value, true
+		}
+		value, exists := defaults[key]
+		return value, exists
+	}
+	if value, exists := lookupFileEnv(c.environ(), envs, key); exists {
+		return value, true
+	}
+	if value, exists := envs[key]; exists {
+		return value, true
+	}
+	if value, exists := c.environ().lookup(key); exists {
+		return value, true
+	}
+	value, exists := defaults[key]
+	return value, exists
+}
+
+func (c *Config) GetOptionalString(key string) Optional[string] {
+	value, exists := c.Lookup(key)
+	return Optional[string]{Value: value, Set: exists}
+}
+
+func (c *Config) GetOptionalBool(key string) (Optional[bool], error) {
+	return LookupOptional(c, key, strconv.ParseBool)
+}
+
+func (c *Config) GetOptionalInt(key string) (Optional[int], error) {
+	return LookupOptional(c, key, strconv.Atoi)
+}
+
+// LookupOptional is the generic form of the GetOptional getters, for any
+// parse function.
+func LookupOptional[T any](c *Config, key string, parse func(string) (T, error)) (Optional[T], error) {
+	strValue, exists := c.Lookup(key)
+	if !exists {
+		return Optional[T]{}, nil
+	}
+	if strValue == "" {
+		return Optional[T]{Set: true}, nil
+	}
+	value, err := parse(strValue)
+	if err != nil {
+		return Optional[T]{}, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return Optional[T]{Value: value, Set: true}, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WithPathKeys declares keys holding paths that must exist and be
+// readable, such as certificate files, checked by NewConfig.
+func WithPathKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parsePath(value)
+		return err
+	})
+}
+
+// WithDirKeys is like WithPathKeys but also requires a directory.
+func WithDirKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parseDir(value)
+		return err
+	})
+}
+
+// GetPath returns the absolute form of a path value, with a leading ~
+// expanded to the home directory and relative paths resolved against the
+// working directory. The path must exist and be readable.
+func (c *Config) GetPath(key string) (string, error) {
+	return getValue(c, key, parsePath)
+}
+
+func (c *Config) GetPathWithFallback(key, fallback string) string {
+	return getValueWithFallback(c, key, "path", fallback, parsePath)
+}
+
+func (c *Config) GetDir(key string) (string, error) {
+	return getValue(c, key, parseDir)
+}
+
+func (c *Config) GetDirWithFallback(key, fallback string) string {
+	return getValueWithFallback(c, key, "directory", fallback, parseDir)
+}
+
+func parsePath(s string) (string, error) {
+	path, err := expandPath(s)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return path, nil
+}
+
+func parseDir(s string) (string, error) {
+	path, err := parsePath(s)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+	return path, nil
+}
+
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return filepath.Abs(path)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func WithPlistFile(file string) Option {
+	return withFile("plist", file, decodePlist)
+}
+
+// NewPlistSource reads a property list file, such as a managed preferences
+// file deployed by MDM under /Library/Managed Preferences.
+func NewPlistSource(file string) Source {
+	return newFileSource("plist", file, decodePlist, defaultOverlay)
+}
+
+func decodePlist(data []byte) (any, error) {
+	if bytes.HasPrefix(data, []byte("bplist")) {
+		converted, err := convertBinaryPlist(data)
+		if err != nil {
+			return nil, err
+		}
+		data = converted
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("empty property list")
+			}
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local == "plist" {
+			continue
+		}
+		value, err := decodePlistValue(dec, start)
+		if err != nil {
+			return nil, err
+		}
+		doc, ok := value.(map[string]any)
+		if !ok {
+			return nil, errors.New("property list root must be a dict")
+		}
+		return doc, nil
+	}
+}
+
+func decodePlistValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		var key string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					var k string
+					if err := dec.DecodeElement(&k, &t); err != nil {
+						return nil, err
+					}
+					key = k
+					continue
+				}
+				value, err := decodePlistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var list []any
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			case xml.EndElement:
+				return list, nil
+			}
+		}
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	case "string", "integer", "real", "date", "data":
+		var text string
+		if err := dec.DecodeElement(&text, &start); err != nil {
+			return nil, err
+		}
+		text = strings.TrimSpace(text)
+		if start.Name.Local == "data" {
+			decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+			if err != nil {
+				return nil, fmt.Errorf("invalid data element: %w", err)
+			}
+			return string(decoded), nil
+		}
+		return text, nil
+	default:
+		return nil, fmt.Errorf("unsupported plist element <%s>", start.Name.Local)
+	}
+}
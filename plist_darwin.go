@@ -0,0 +1,42 @@
+//go:build darwin
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// NewDefaultsSource reads a preferences domain through `defaults export`,
+// which includes managed preferences pushed by MDM.
+func NewDefaultsSource(domain string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.Command("defaults", "export", domain, "-")
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("error reading defaults domain %s: %w: %s", domain, err, strings.TrimSpace(stderr.String()))
+		}
+
+		doc, err := decodePlist(stdout.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing defaults domain %s: %w", domain, err)
+		}
+		return toEnvs(doc), nil
+	})
+}
+
+func convertBinaryPlist(data []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("plutil", "-convert", "xml1", "-o", "-", "-")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error converting binary plist: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
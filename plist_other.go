@@ -0,0 +1,17 @@
+//go:build !darwin
+
+package config
+
+import (
+	"errors"
+)
+
+func NewDefaultsSource(domain string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		return nil, errors.New("defaults domains are only supported on macOS")
+	})
+}
+
+func convertBinaryPlist(data []byte) ([]byte, error) {
+	return nil, errors.New("binary property lists are only supported on macOS")
+}
@@ -0,0 +1,92 @@
+package config
+
+import "strings"
+
+// Layer is a group of values ordered by WithPrecedence.
+type Layer int
+
+const (
+	// CredentialsLayer holds systemd credentials from $CREDENTIALS_DIRECTORY.
+	CredentialsLayer Layer = iota
+	// DotEnvLayer holds the .env file and its layers.
+	DotEnvLayer
+	// SourcesLayer holds file, remote and custom sources.
+	SourcesLayer
+	// EnvironmentLayer holds the OS environment.
+	EnvironmentLayer
+	// FlagsLayer holds the command-line flags passed to WithFlags.
+	FlagsLayer
+)
+
+// defaultPrecedence is the order used without WithPrecedence, highest first.
+var defaultPrecedence = []Layer{FlagsLayer, CredentialsLayer, DotEnvLayer, SourcesLayer, EnvironmentLayer}
+
+// WithPrecedence sets the order in which layers override each other,
+// highest first, e.g. WithPrecedence(EnvironmentLayer) to let real
+// environment variables win over .env and every source. Layers left out
+// follow in the default order of flags, credentials, .env, sources and the
+// OS environment. Values passed through options and defaults always come
+// last. With a custom order the OS environment is read once, by NewConfig.
+func WithPrecedence(layers ...Layer) Option {
+	return func(c *Config) {
+		c.precedence = layers
+	}
+}
+
+// layerOrder returns every layer, highest first.
+func (c *Config) layerOrder() []Layer {
+	seen := make(map[Layer]bool)
+	var order []Layer
+	for _, layer := range append(append([]Layer(nil), c.precedence...), defaultPrecedence...) {
+		if !seen[layer] {
+			seen[layer] = true
+			order = append(order, layer)
+		}
+	}
+	return order
+}
+
+// mergeLayers merges the layers into one map by precedence. Unless the
+// order is customised, the OS environment stays out of it and is read on
+// each lookup, below every other layer. A layer setting KEY or KEY_FILE
+// hides both from the layers below, so --database-url beats a
+// DATABASE_URL_FILE in the .env file.
+func (c *Config) mergeLayers(layers map[Layer]map[string]string) map[string]string {
+	if c.precedence != nil {
+		layers[EnvironmentLayer] = environMap(c.environ())
+	}
+	envs := make(map[string]string)
+	order := c.layerOrder()
+	for i := len(order) - 1; i >= 0; i-- {
+		hideLower(envs, layers[order[i]])
+		c.overlay(envs, layers[order[i]])
+	}
+	return envs
+}
+
+// hideLower removes from envs the KEY_FILE of every KEY set in layer and the
+// KEY of every KEY_FILE, before layer is merged over envs.
+func hideLower(envs, layer map[string]string) {
+	for key, value := range layer {
+		if value == "" {
+			continue
+		}
+		if base, ok := strings.CutSuffix(key, "_FILE"); ok {
+			delete(envs, base)
+		} else {
+			delete(envs, key+"_FILE")
+		}
+	}
+}
+
+// environMap returns the non-empty OS environment variables, since empty
+// ones count as unset and must not hide the layers below.
+func environMap(env *environment) map[string]string {
+	envs := make(map[string]string)
+	for _, name := range env.names() {
+		if value, _ := env.lookup(name); value != "" {
+			envs[name] = value
+		}
+	}
+	return envs
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// WithEnvPrefix reads every key from the OS environment under prefix, so
+// PORT comes from MYAPP_PORT and DATABASE_URL from MYAPP_DATABASE_URL, and
+// services sharing a host or a test process do not collide. Unprefixed
+// variables are ignored unless WithUnprefixedFallback is given. The .env
+// file and other sources keep their plain keys. The environment is read
+// once, by NewConfig.
+func WithEnvPrefix(prefix string) Option {
+	return func(c *Config) {
+		c.envPrefix = prefix
+	}
+}
+
+// WithUnprefixedFallback lets WithEnvPrefix fall back to unprefixed
+// variables, e.g. PORT when MYAPP_PORT is not set, while deployments
+// migrate to the prefixed names.
+func WithUnprefixedFallback() Option {
+	return func(c *Config) {
+		c.envFallback = true
+	}
+}
+
+// environment is the part of the OS environment a Config reads, and the
+// files read for its KEY_FILE variables.
+type environment struct {
+	// vars holds the variables as narrowed by WithEnvPrefix; nil stands for
+	// the live process environment.
+	vars map[string]string
+
+	// mu guards the fields below, which Get updates from any goroutine.
+	mu sync.Mutex
+	// files holds the contents of the files read so far, by path, and
+	// failed the reads that failed, so that each file is read once. errs
+	// holds the failures for the load, or a Bind in progress, to report.
+	files   map[string]string
+	failed  map[string]error
+	errs    []error
+	loaded  bool
+	binding int
+}
+
+func (e *environment) lookup(key string) (string, bool) {
+	if e == nil || e.vars == nil {
+		return os.LookupEnv(key)
+	}
+	value, exists := e.vars[key]
+	return value, exists
+}
+
+func (e *environment) names() []string {
+	var names []string
+	if e == nil || e.vars == nil {
+		for _, env := range os.Environ() {
+			name, _, _ := strings.Cut(env, "=")
+			names = append(names, name)
+		}
+		return names
+	}
+	for name := range e.vars {
+		names = append(names, name)
+	}
+	return names
+}
+
+// readFile returns the contents of the file path named by KEY_FILE.
+// Failures are kept for the load, or a Bind, to report, and logged once
+// NewConfig, Load or LoadSnapshot has returned, when they can no longer
+// fail it.
+func (e *environment) readFile(key, path string) (string, bool) {
+	if e == nil {
+		value, err := readSecretFile(key, path)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			return "", false
+		}
+		return value, true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if value, ok := e.files[path]; ok {
+		return value, true
+	}
+	err, failed := e.failed[path]
+	if !failed {
+		var value string
+		if value, err = readSecretFile(key, path); err == nil {
+			if e.files == nil {
+				e.files = make(map[string]string)
+			}
+			e.files[path] = value
+			return value, true
+		}
+		if e.failed == nil {
+			e.failed = make(map[string]error)
+		}
+		e.failed[path] = err
+		if e.loaded && e.binding == 0 {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	if !e.loaded || e.binding > 0 {
+		e.errs = append(e.errs, err)
+	}
+	return "", false
+}
+
+func readSecretFile(key, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// err reports the KEY_FILE files that could not be read, after the first
+// since failures.
+func (e *environment) err(since int) error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var errs []error
+	seen := make(map[string]bool)
+	for _, err := range e.errs[since:] {
+		if !seen[err.Error()] {
+			seen[err.Error()] = true
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// markLoaded ends the load: later failures are only logged.
+func (e *environment) markLoaded() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.loaded = true
+	e.errs = nil
+}
+
+// startBind collects failures again until the returned function is called,
+// and reports how many were already collected, for err.
+func (e *environment) startBind() (since int, done func()) {
+	if e == nil {
+		return 0, func() {}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.binding++
+	return len(e.errs), func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if e.binding--; e.binding == 0 && e.loaded {
+			e.errs = nil
+		}
+	}
+}
+
+// prefixedEnvironment returns the variables starting with prefix under
+// their unprefixed names, over the unprefixed variables if fallback is set.
+func prefixedEnvironment(prefix string, fallback bool) map[string]string {
+	env := make(map[string]string)
+	var unprefixed []string
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			env[key] = value
+		} else if fallback {
+			unprefixed = append(unprefixed, entry)
+		}
+	}
+	for _, entry := range unprefixed {
+		name, value, _ := strings.Cut(entry, "=")
+		if _, exists := env[name]; !exists {
+			env[name] = value
+		}
+	}
+	return env
+}
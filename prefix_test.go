@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFileKeysConcurrentGet(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadSnapshot(
+		WithEnvFile(emptyEnvFile(t)),
+		WithOverrides(map[string]string{"API_TOKEN_FILE": secret}),
+	)
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	// A live Config, rather than a snapshot, reads through the KEY_FILE
+	// cache on every Get.
+	cfg, err := load([]Option{
+		WithEnvFile(emptyEnvFile(t)),
+		WithOverrides(map[string]string{"API_TOKEN_FILE": secret, "MISSING_FILE": filepath.Join(dir, "missing")}),
+	})
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	cfg.environ().markLoaded()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if got := cfg.Get("API_TOKEN"); got != "s3cret" {
+					t.Errorf("Get(API_TOKEN) = %q, want s3cret", got)
+				}
+				if got := cfg.Get("MISSING"); got != "" {
+					t.Errorf("Get(MISSING) = %q, want empty", got)
+				}
+				if got := c.Get("API_TOKEN"); got != "s3cret" {
+					t.Errorf("snapshot Get(API_TOKEN) = %q, want s3cret", got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	env := cfg.environ()
+	if len(env.errs) != 0 {
+		t.Errorf("failures kept after load: %v", env.errs)
+	}
+	if len(env.failed) != 1 {
+		t.Errorf("failed = %v, want one path", env.failed)
+	}
+}
+
+func TestFileKeysFailBind(t *testing.T) {
+	dir := t.TempDir()
+	c, err := NewConfig(
+		WithEnvFile(emptyEnvFile(t)),
+		WithDatabaseURL("postgres://db"),
+		WithAuthServiceURL("http://auth"),
+		WithOverrides(map[string]string{"WORKERS_FILE": filepath.Join(dir, "missing")}),
+	)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	var v struct {
+		Workers int `env:"WORKERS"`
+	}
+	for i := 0; i < 2; i++ {
+		err := c.Bind(&v)
+		if err == nil || !strings.Contains(err.Error(), "could not read WORKERS_FILE") {
+			t.Fatalf("Bind #%d = %v, want a WORKERS_FILE error", i+1, err)
+		}
+	}
+	if c.Get("WORKERS") != "" || len(c.environ().errs) != 0 {
+		t.Errorf("failures kept after Bind: %v", c.environ().errs)
+	}
+}
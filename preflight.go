@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Preflight is an opt-in validator checking that the services a
+// configuration points at answer: DATABASE_URL must accept a connection and
+// AUTH_SERVICE_URL must answer its health path with a 2xx status. Add it
+// with WithValidator(NewPreflight()) for CI smoke tests or init containers.
+// Keys that are not set or do not parse are skipped, as the built-in
+// checks report them.
+type Preflight struct {
+	// HealthPath is resolved against AUTH_SERVICE_URL like a link, so
+	// "/healthz" is at the root of its host.
+	HealthPath string
+	// Timeout bounds each check; 5s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+}
+
+const defaultPreflightTimeout = 5 * time.Second
+
+func NewPreflight() *Preflight {
+	return &Preflight{
+		HealthPath: "/healthz",
+		Timeout:    defaultPreflightTimeout,
+	}
+}
+
+func (p *Preflight) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return defaultPreflightTimeout
+	}
+	return p.Timeout
+}
+
+// PreflightError reports a failed preflight check.
+type PreflightError struct {
+	// Target is "database" or "auth service".
+	Target string
+	// Address is the dialled address or the requested URL, without
+	// credentials.
+	Address string
+	Elapsed time.Duration
+	Err     error
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("preflight: %s at %s failed after %s: %v", e.Target, e.Address, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+func (e *PreflightError) Unwrap() error {
+	return e.Err
+}
+
+func (p *Preflight) Validate(c *Config) error {
+	return p.Run(context.Background(), c)
+}
+
+// Run performs the checks, reporting every failure.
+func (p *Preflight) Run(ctx context.Context, c *Config) error {
+	var errs []error
+	if dsn, err := parseDSN(c.Get("DATABASE_URL")); err == nil {
+		errs = append(errs, p.checkDatabase(ctx, dsn))
+	}
+	if endpoint, err := parseEndpoint(c.Get("AUTH_SERVICE_URL")); err == nil {
+		errs = append(errs, p.checkHealth(ctx, endpoint))
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Preflight) checkDatabase(ctx context.Context, dsn *DSN) error {
+	start := time.Now()
+	if dsn.Scheme == "sqlite" {
+		if dsn.Database == ":memory:" {
+			return nil
+		}
+		if _, err := os.Stat(dsn.Database); err != nil {
+			return &PreflightError{Target: "database", Address: dsn.Database, Elapsed: time.Since(start), Err: err}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+	network := "tcp"
+	if dsn.Port == 0 {
+		network = "unix"
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, dsn.Address())
+	if err != nil {
+		return &PreflightError{Target: "database", Address: dsn.Address(), Elapsed: time.Since(start), Err: err}
+	}
+	return conn.Close()
+}
+
+func (p *Preflight) checkHealth(ctx context.Context, endpoint *url.URL) error {
+	start := time.Now()
+	health := endpoint.ResolveReference(&url.URL{Path: p.HealthPath})
+	fail := func(err error) error {
+		return &PreflightError{Target: "auth service", Address: health.Redacted(), Elapsed: time.Since(start), Err: err}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, health.String(), nil)
+	if err != nil {
+		return fail(err)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail(stripURLError(err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fail(fmt.Errorf("unexpected status %s", resp.Status))
+	}
+	return nil
+}
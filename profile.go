@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type profile struct {
+	name string
+	opts []Option
+}
+
+// WithProfile applies opts only when the active profile is name, compared
+// case-insensitively. The profile is read from APP_ENV in flags, the OS
+// environment or the .env file, or from WithValue("APP_ENV", ...):
+//
+//	config.WithProfile("prod",
+//		config.WithJSONSchemaFile("prod.schema.json"),
+//		config.WithValidator(config.RequiredIf("APP_ENV", "SENTRY_DSN")),
+//	)
+//
+// Profile options may add sources, defaults and validators; the .env file
+// is already read when they apply.
+func WithProfile(name string, opts ...Option) Option {
+	return func(c *Config) {
+		c.profiles = append(c.profiles, profile{name: name, opts: opts})
+	}
+}
+
+// WithProfileFile loads the file named by pattern with %s replaced by the
+// active profile, e.g. "config.%s.env" loads config.prod.env when
+// APP_ENV=prod. The format is picked from the extension, and a missing file
+// is skipped with a warning, like other files.
+func WithProfileFile(pattern string) Option {
+	return func(c *Config) {
+		c.sources = append(c.sources, SourceFunc(func() (map[string]string, error) {
+			if c.profile == "" {
+				return nil, nil
+			}
+			return loadFileByExt(fmt.Sprintf(pattern, c.profile), c.overlay)
+		}))
+	}
+}
+
+// Profile returns the active profile, e.g. "prod", or "" if APP_ENV is not
+// set.
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// applyProfile activates the profile named by APP_ENV, with overrides,
+// flags, the .env file and the OS environment in their order of
+// precedence, and applies its options.
+func (c *Config) applyProfile(dotenv map[string]string) {
+	envs := c.mergeLayers(map[Layer]map[string]string{FlagsLayer: c.flagValues(), DotEnvLayer: dotenv})
+	hideLower(envs, c.resolved.overrides)
+	mergeEnvs(envs, c.resolved.overrides)
+	c.profile = getEnvWithFallback(c.environ(), envs, "APP_ENV", c.resolved.values["APP_ENV"])
+	if c.profile == "" {
+		return
+	}
+	for _, p := range c.profiles {
+		if strings.EqualFold(p.name, c.profile) {
+			for _, opt := range p.opts {
+				opt(c)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WithRatioKeys declares keys holding ratios, checked by NewConfig.
+func WithRatioKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parseRatio(value)
+		return err
+	})
+}
+
+// GetRatio reads a ratio such as SAMPLING_RATE=0.25 or ROLLOUT=15% as a
+// float in [0, 1].
+func (c *Config) GetRatio(key string) (float64, error) {
+	return getValue(c, key, parseRatio)
+}
+
+func (c *Config) GetRatioWithFallback(key string, fallback float64) float64 {
+	return getValueWithFallback(c, key, "ratio", fallback, parseRatio)
+}
+
+func parseRatio(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	number, percent := strings.CutSuffix(s, "%")
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a ratio or percentage", s)
+	}
+	if percent {
+		value /= 100
+	}
+	if !(value >= 0 && value <= 1) {
+		return 0, fmt.Errorf("%q is outside the range 0-1 (0%%-100%%)", s)
+	}
+	return value, nil
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisSource reads either a hash (all its fields) or every key under a
+// prefix. Watching subscribes to Channel; any message published there
+// triggers a reload.
+type RedisSource struct {
+	// URL is a redis:// or rediss:// URL with optional credentials and
+	// database number, e.g. redis://:secret@localhost:6379/0.
+	URL     string
+	Hash    string
+	Prefix  string
+	Channel string
+	// Timeout defaults to 5s when not positive.
+	Timeout time.Duration
+}
+
+const (
+	defaultRedisTimeout = 5 * time.Second
+	// redisMaxBulkLength is Redis's own proto-max-bulk-len default, so a
+	// corrupt length cannot make read allocate more than a server could send.
+	redisMaxBulkLength = 512 << 20
+)
+
+func NewRedisHashSource(redisURL, hash string) *RedisSource {
+	return &RedisSource{URL: redisURL, Hash: hash, Timeout: defaultRedisTimeout}
+}
+
+func NewRedisPrefixSource(redisURL, prefix string) *RedisSource {
+	return &RedisSource{URL: redisURL, Prefix: prefix, Timeout: defaultRedisTimeout}
+}
+
+func (s *RedisSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if s.Hash != "" {
+		reply, err := conn.do("HGETALL", s.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("error reading Redis hash %s: %w", s.Hash, err)
+		}
+		fields, _ := reply.([]any)
+		envs := make(map[string]string, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			envs[redisString(fields[i])] = redisString(fields[i+1])
+		}
+		return envs, nil
+	}
+
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := conn.do("SCAN", cursor, "MATCH", redisEscapePattern(s.Prefix)+"*", "COUNT", "100")
+		if err != nil {
+			return nil, fmt.Errorf("error scanning Redis prefix %s: %w", s.Prefix, err)
+		}
+		parts, _ := reply.([]any)
+		if len(parts) != 2 {
+			return nil, errors.New("unexpected SCAN reply")
+		}
+		batch, _ := parts[1].([]any)
+		for _, key := range batch {
+			keys = append(keys, redisString(key))
+		}
+		cursor = redisString(parts[0])
+		if cursor == "0" {
+			break
+		}
+	}
+
+	envs := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return envs, nil
+	}
+	reply, err := conn.do("MGET", keys...)
+	if err != nil {
+		return nil, fmt.Errorf("error reading Redis prefix %s: %w", s.Prefix, err)
+	}
+	values, _ := reply.([]any)
+	for i, key := range keys {
+		if i < len(values) && values[i] != nil {
+			envs[normalizePathKey(strings.TrimPrefix(key, s.Prefix))] = redisString(values[i])
+		}
+	}
+	return envs, nil
+}
+
+func (s *RedisSource) Watch(ctx context.Context, changed func()) error {
+	if s.Channel == "" {
+		return errors.New("Redis source has no Channel to watch")
+	}
+
+	for {
+		err := s.subscribe(ctx, changed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logWatchError("Redis", s.Channel, err)
+		if !sleepContext(ctx, 2*time.Second) {
+			return nil
+		}
+	}
+}
+
+func (s *RedisSource) subscribe(ctx context.Context, changed func()) error {
+	dialCtx, cancel := context.WithTimeout(ctx, s.timeout())
+	conn, err := s.dial(dialCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn.conn.SetDeadline(time.Time{})
+	if err := conn.send("SUBSCRIBE", s.Channel); err != nil {
+		return err
+	}
+	for {
+		reply, err := conn.read()
+		if err != nil {
+			return err
+		}
+		msg, _ := reply.([]any)
+		if len(msg) == 3 && redisString(msg[0]) == "message" {
+			changed()
+		}
+	}
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func (s *RedisSource) dial(ctx context.Context) (*redisConn, error) {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	switch u.Scheme {
+	case "redis":
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	case "rediss":
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}}).DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("unsupported Redis URL scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Redis at %s: %w", host, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if password, ok := u.User.Password(); ok {
+		args := []string{password}
+		if user := u.User.Username(); user != "" {
+			args = []string{user, password}
+		}
+		if _, err := c.do("AUTH", args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error authenticating to Redis: %w", err)
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" && db != "0" {
+		if _, err := c.do("SELECT", db); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("error selecting Redis database %s: %w", db, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *redisConn) do(command string, args ...string) (any, error) {
+	if err := c.send(command, args...); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+func (c *redisConn) send(command string, args ...string) error {
+	fmt.Fprintf(c.w, "*%d\r\n$%d\r\n%s\r\n", len(args)+1, len(command), command)
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return c.w.Flush()
+}
+
+func (c *redisConn) read() (any, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty Redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > redisMaxBulkLength {
+			return nil, fmt.Errorf("Redis bulk string of %d bytes is too long", n)
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		if string(buf[n:]) != "\r\n" {
+			return nil, errors.New("malformed Redis bulk string")
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		// Grown as items arrive rather than sized from n, which a corrupt
+		// reply controls.
+		items := make([]any, 0, min(n, 64))
+		for range n {
+			item, err := c.read()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected Redis reply %q", line)
+	}
+}
+
+func (s *RedisSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultRedisTimeout)
+}
+
+func redisString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+func redisEscapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
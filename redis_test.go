@@ -0,0 +1,160 @@
+package config
+
+import (
+	"bufio"
+	"maps"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRedisRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    any
+		wantErr string
+	}{
+		{"simple string", "+OK\r\n", "OK", ""},
+		{"error", "-ERR unknown command\r\n", nil, "ERR unknown command"},
+		{"integer", ":42\r\n", int64(42), ""},
+		{"bulk string", "$5\r\nhello\r\n", "hello", ""},
+		{"bulk string with CRLF inside", "$7\r\nhel\r\nlo\r\n", "hel\r\nlo", ""},
+		{"empty bulk string", "$0\r\n\r\n", "", ""},
+		{"null bulk string", "$-1\r\n", nil, ""},
+		{"array", "*2\r\n$3\r\nfoo\r\n:7\r\n", []any{"foo", int64(7)}, ""},
+		{"empty array", "*0\r\n", []any{}, ""},
+		{"null array", "*-1\r\n", nil, ""},
+		{"nested array", "*2\r\n$1\r\n0\r\n*1\r\n$4\r\nAPP_\r\n", []any{"0", []any{"APP_"}}, ""},
+		{"array with null item", "*2\r\n$-1\r\n$1\r\nx\r\n", []any{nil, "x"}, ""},
+		{"empty input", "", nil, "EOF"},
+		{"truncated line", "+OK", nil, "EOF"},
+		{"truncated bulk string", "$5\r\nhel", nil, "unexpected EOF"},
+		{"bulk string longer than its length", "$3\r\nhello\r\n", nil, "malformed Redis bulk string"},
+		{"huge bulk string", "$99999999999\r\n", nil, "Redis bulk string of 99999999999 bytes is too long"},
+		{"truncated array", "*3\r\n:1\r\n:2\r\n", nil, "EOF"},
+		{"huge array", "*99999999999\r\n:1\r\n", nil, "EOF"},
+		{"bad integer", ":x\r\n", nil, `strconv.ParseInt: parsing "x": invalid syntax`},
+		{"bad bulk length", "$x\r\n", nil, `strconv.Atoi: parsing "x": invalid syntax`},
+		{"bad array length", "*x\r\n", nil, `strconv.Atoi: parsing "x": invalid syntax`},
+		{"empty reply", "\r\n", nil, "empty Redis reply"},
+		{"unknown type", "%2\r\n", nil, `unexpected Redis reply "%2"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &redisConn{r: bufio.NewReader(strings.NewReader(tt.reply))}
+			got, err := c.read()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("read = %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read = %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisSend(t *testing.T) {
+	var out strings.Builder
+	c := &redisConn{w: bufio.NewWriter(&out)}
+	if err := c.send("HGETALL", "app:config"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "*2\r\n$7\r\nHGETALL\r\n$10\r\napp:config\r\n"; out.String() != want {
+		t.Errorf("send wrote %q, want %q", out.String(), want)
+	}
+}
+
+func TestRedisSource(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// Each connection answers the commands it receives in order with the
+	// canned replies of the test case.
+	replies := make(chan []string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+			for _, reply := range <-replies {
+				if _, err := c.read(); err != nil {
+					break
+				}
+				c.w.WriteString(reply)
+				c.w.Flush()
+			}
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		name    string
+		source  *RedisSource
+		replies []string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "hash",
+			source:  &RedisSource{URL: "redis://" + ln.Addr().String(), Hash: "app"},
+			replies: []string{"*4\r\n$4\r\nPORT\r\n$4\r\n8080\r\n$5\r\nDEBUG\r\n$4\r\ntrue\r\n"},
+			want:    map[string]string{"PORT": "8080", "DEBUG": "true"},
+		},
+		{
+			name:   "prefix over two SCAN pages",
+			source: &RedisSource{URL: "redis://:secret@" + ln.Addr().String() + "/2", Prefix: "app/"},
+			replies: []string{
+				"+OK\r\n",
+				"+OK\r\n",
+				"*2\r\n$2\r\n17\r\n*1\r\n$16\r\napp/database/url\r\n",
+				"*2\r\n$1\r\n0\r\n*2\r\n$8\r\napp/port\r\n$8\r\napp/gone\r\n",
+				"*3\r\n$13\r\npostgres://db\r\n$4\r\n8080\r\n$-1\r\n",
+			},
+			want: map[string]string{"DATABASE_URL": "postgres://db", "PORT": "8080"},
+		},
+		{
+			name:    "rejected password",
+			source:  &RedisSource{URL: "redis://:wrong@" + ln.Addr().String(), Hash: "app"},
+			replies: []string{"-WRONGPASS invalid username-password pair\r\n"},
+			wantErr: "error authenticating to Redis: WRONGPASS invalid username-password pair",
+		},
+		{
+			name:    "malformed SCAN reply",
+			source:  &RedisSource{URL: "redis://" + ln.Addr().String(), Prefix: "app/"},
+			replies: []string{"*1\r\n$1\r\n0\r\n"},
+			wantErr: "unexpected SCAN reply",
+		},
+		{
+			name:    "connection closed mid-reply",
+			source:  &RedisSource{URL: "redis://" + ln.Addr().String(), Hash: "app"},
+			replies: []string{"*2\r\n$4\r\nPORT\r\n"},
+			wantErr: "error reading Redis hash app: EOF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Struct literals, without the constructors' timeout.
+			replies <- tt.replies
+			got, err := tt.source.Load()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load = %v, %v, want error %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, tt.want) {
+				t.Errorf("Load = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import "regexp"
+
+// WithRegexpKeys declares keys holding regular expressions. They are
+// compiled by NewConfig, which fails on invalid patterns, and GetRegexp then
+// returns the compiled form.
+func WithRegexpKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return err
+		}
+		if c.regexps == nil {
+			c.regexps = make(map[string]*regexp.Regexp)
+		}
+		c.regexps[value] = re
+		return nil
+	})
+}
+
+func (c *Config) GetRegexp(key string) (*regexp.Regexp, error) {
+	return getValue(c, key, c.compileRegexp)
+}
+
+func (c *Config) GetRegexpWithFallback(key string, fallback *regexp.Regexp) *regexp.Regexp {
+	return getValueWithFallback(c, key, "regexp", fallback, c.compileRegexp)
+}
+
+func (c *Config) compileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := c.regexps[pattern]; ok {
+		return re, nil
+	}
+	return regexp.Compile(pattern)
+}
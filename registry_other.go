@@ -0,0 +1,13 @@
+//go:build !windows
+
+package config
+
+import (
+	"errors"
+)
+
+func NewRegistrySource(path string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		return nil, errors.New("registry sources are only supported on Windows")
+	})
+}
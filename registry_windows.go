@@ -0,0 +1,105 @@
+//go:build windows
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+var registryRoots = map[string]registry.Key{
+	"HKLM":                registry.LOCAL_MACHINE,
+	"HKEY_LOCAL_MACHINE":  registry.LOCAL_MACHINE,
+	"HKCU":                registry.CURRENT_USER,
+	"HKEY_CURRENT_USER":   registry.CURRENT_USER,
+	"HKCR":                registry.CLASSES_ROOT,
+	"HKEY_CLASSES_ROOT":   registry.CLASSES_ROOT,
+	"HKU":                 registry.USERS,
+	"HKEY_USERS":          registry.USERS,
+	"HKCC":                registry.CURRENT_CONFIG,
+	"HKEY_CURRENT_CONFIG": registry.CURRENT_CONFIG,
+}
+
+// NewRegistrySource reads the values under a registry key such as
+// HKLM\Software\MyApp. Subkeys become key prefixes, so the value Url under
+// HKLM\Software\MyApp\Database is exposed as DATABASE_URL.
+func NewRegistrySource(path string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		rootName, subPath, _ := strings.Cut(path, `\`)
+		root, ok := registryRoots[strings.ToUpper(rootName)]
+		if !ok {
+			return nil, fmt.Errorf("unknown registry root %q", rootName)
+		}
+
+		envs := make(map[string]string)
+		if err := readRegistryKey(envs, root, subPath, ""); err != nil {
+			if errors.Is(err, registry.ErrNotExist) {
+				return envs, nil
+			}
+			return nil, fmt.Errorf("error reading registry key %s: %w", path, err)
+		}
+		return envs, nil
+	})
+}
+
+func readRegistryKey(envs map[string]string, root registry.Key, path, prefix string) error {
+	key, err := registry.OpenKey(root, path, registry.QUERY_VALUE|registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return err
+	}
+	defer key.Close()
+
+	names, err := key.ReadValueNames(0)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		value, err := readRegistryValue(key, name)
+		if err != nil {
+			return fmt.Errorf("value %s: %w", name, err)
+		}
+		envs[joinKey(prefix, name)] = value
+	}
+
+	subkeys, err := key.ReadSubKeyNames(0)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subkeys {
+		if err := readRegistryKey(envs, root, path+`\`+sub, joinKey(prefix, sub)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readRegistryValue(key registry.Key, name string) (string, error) {
+	_, valType, err := key.GetValue(name, nil)
+	if err != nil {
+		return "", err
+	}
+
+	switch valType {
+	case registry.SZ, registry.EXPAND_SZ:
+		value, _, err := key.GetStringValue(name)
+		if err != nil {
+			return "", err
+		}
+		if valType == registry.EXPAND_SZ {
+			return registry.ExpandString(value)
+		}
+		return value, nil
+	case registry.DWORD, registry.QWORD:
+		value, _, err := key.GetIntegerValue(name)
+		return strconv.FormatUint(value, 10), err
+	case registry.MULTI_SZ:
+		values, _, err := key.GetStringsValue(name)
+		return strings.Join(values, ","), err
+	default:
+		return "", fmt.Errorf("unsupported registry value type %d", valType)
+	}
+}
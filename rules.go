@@ -0,0 +1,146 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequiredIf requires the given keys whenever key is enabled, i.e. set to
+// anything but a false boolean:
+//
+//	config.WithValidator(config.RequiredIf("TLS_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE"))
+func RequiredIf(key string, required ...string) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		value := c.Get(key)
+		if value == "" {
+			return nil
+		}
+		if enabled, err := strconv.ParseBool(value); err == nil && !enabled {
+			return nil
+		}
+
+		var errs []error
+		for _, name := range required {
+			if c.Get(name) == "" {
+				errs = append(errs, fmt.Errorf("%s is required when %s is set", name, key))
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// RequiredWhen requires the given keys when key holds value, compared
+// case-insensitively:
+//
+//	config.WithValidator(config.RequiredWhen("STORAGE", "s3", "S3_BUCKET", "S3_REGION"))
+func RequiredWhen(key, value string, required ...string) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		if !strings.EqualFold(c.Get(key), value) {
+			return nil
+		}
+
+		var errs []error
+		for _, name := range required {
+			if c.Get(name) == "" {
+				errs = append(errs, fmt.Errorf("%s is required when %s=%s", name, key, value))
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// ExactlyOneOf requires one and only one of keys to be set, e.g. REDIS_URL
+// or REDIS_SENTINEL_ADDRS.
+func ExactlyOneOf(keys ...string) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		set := setKeys(c, keys)
+		if len(set) == 0 {
+			return fmt.Errorf("one of %s must be set", joinKeys(keys, "or"))
+		}
+		return checkExclusive(keys, set)
+	})
+}
+
+// MutuallyExclusive allows at most one of keys to be set.
+func MutuallyExclusive(keys ...string) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		return checkExclusive(keys, setKeys(c, keys))
+	})
+}
+
+func checkExclusive(keys, set []string) error {
+	if len(set) > 1 {
+		return fmt.Errorf("only one of %s may be set, got %s", joinKeys(keys, "or"), joinKeys(set, "and"))
+	}
+	return nil
+}
+
+func setKeys(c *Config, keys []string) []string {
+	var set []string
+	for _, key := range keys {
+		if c.Get(key) != "" {
+			set = append(set, key)
+		}
+	}
+	return set
+}
+
+// joinKeys lists keys as "A, B or C".
+func joinKeys(keys []string, conjunction string) string {
+	if len(keys) < 2 {
+		return strings.Join(keys, "")
+	}
+	return strings.Join(keys[:len(keys)-1], ", ") + " " + conjunction + " " + keys[len(keys)-1]
+}
+
+// LessThan requires the value of key to be below that of other, e.g.
+// READ_TIMEOUT below IDLE_TIMEOUT. The values are compared as durations
+// when both are ones, such as 0 and 10s, or else as numbers; the rule is
+// skipped unless both keys are set.
+func LessThan(key, other string) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		a, b := c.Get(key), c.Get(other)
+		if a == "" || b == "" {
+			return nil
+		}
+
+		less, err := compareValues(a, b)
+		if err != nil {
+			return fmt.Errorf("cannot compare %s and %s: %w", key, other, err)
+		}
+		if !less {
+			return fmt.Errorf("%s (%s) must be less than %s (%s)", key, a, other, b)
+		}
+		return nil
+	})
+}
+
+func compareValues(a, b string) (bool, error) {
+	x, errA := time.ParseDuration(a)
+	y, errB := time.ParseDuration(b)
+	if errA == nil && errB == nil {
+		return x < y, nil
+	}
+	m, errA := strconv.ParseFloat(a, 64)
+	n, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		return m < n, nil
+	}
+	for _, value := range []string{a, b} {
+		if !isDurationOrNumber(value) {
+			return false, fmt.Errorf("%q is neither a duration nor a number", value)
+		}
+	}
+	return false, fmt.Errorf("%q and %q are not both durations or both numbers", a, b)
+}
+
+func isDurationOrNumber(value string) bool {
+	if _, err := time.ParseDuration(value); err == nil {
+		return true
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import "testing"
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator Validator
+		values    map[string]string
+		want      string
+	}{
+		{"RequiredIf unset", RequiredIf("TLS_ENABLED", "TLS_CERT_FILE"), nil, ""},
+		{"RequiredIf false", RequiredIf("TLS_ENABLED", "TLS_CERT_FILE"), map[string]string{"TLS_ENABLED": "false"}, ""},
+		{"RequiredIf satisfied", RequiredIf("TLS_ENABLED", "TLS_CERT_FILE"), map[string]string{"TLS_ENABLED": "true", "TLS_CERT_FILE": "cert.pem"}, ""},
+		{
+			"RequiredIf missing", RequiredIf("TLS_ENABLED", "TLS_CERT_FILE", "TLS_KEY_FILE"),
+			map[string]string{"TLS_ENABLED": "1"},
+			"TLS_CERT_FILE is required when TLS_ENABLED is set\nTLS_KEY_FILE is required when TLS_ENABLED is set",
+		},
+		{
+			"RequiredIf any non-boolean value enables", RequiredIf("PROXY", "PROXY_AUTH"),
+			map[string]string{"PROXY": "http://proxy"},
+			"PROXY_AUTH is required when PROXY is set",
+		},
+
+		{"RequiredWhen other value", RequiredWhen("STORAGE", "s3", "S3_BUCKET"), map[string]string{"STORAGE": "disk"}, ""},
+		{"RequiredWhen satisfied", RequiredWhen("STORAGE", "s3", "S3_BUCKET"), map[string]string{"STORAGE": "s3", "S3_BUCKET": "b"}, ""},
+		{
+			"RequiredWhen case-insensitive", RequiredWhen("STORAGE", "s3", "S3_BUCKET", "S3_REGION"),
+			map[string]string{"STORAGE": "S3", "S3_REGION": "eu-west-1"},
+			"S3_BUCKET is required when STORAGE=s3",
+		},
+
+		{"ExactlyOneOf one", ExactlyOneOf("REDIS_URL", "REDIS_SENTINEL_ADDRS"), map[string]string{"REDIS_URL": "redis://r"}, ""},
+		{"ExactlyOneOf none", ExactlyOneOf("A", "B", "C"), nil, "one of A, B or C must be set"},
+		{
+			"ExactlyOneOf two", ExactlyOneOf("A", "B", "C"),
+			map[string]string{"A": "1", "C": "1"},
+			"only one of A, B or C may be set, got A and C",
+		},
+		{"ExactlyOneOf empty counts as unset", ExactlyOneOf("A", "B"), map[string]string{"A": "1", "B": ""}, ""},
+
+		{"MutuallyExclusive none", MutuallyExclusive("A", "B"), nil, ""},
+		{"MutuallyExclusive one", MutuallyExclusive("A", "B"), map[string]string{"B": "1"}, ""},
+		{"MutuallyExclusive two", MutuallyExclusive("A", "B"), map[string]string{"A": "1", "B": "1"}, "only one of A or B may be set, got A and B"},
+
+		{"LessThan unset", LessThan("MIN", "MAX"), map[string]string{"MIN": "5"}, ""},
+		{"LessThan durations", LessThan("READ_TIMEOUT", "IDLE_TIMEOUT"), map[string]string{"READ_TIMEOUT": "5s", "IDLE_TIMEOUT": "1m"}, ""},
+		{
+			"LessThan durations out of order", LessThan("READ_TIMEOUT", "IDLE_TIMEOUT"),
+			map[string]string{"READ_TIMEOUT": "2m", "IDLE_TIMEOUT": "1m"},
+			"READ_TIMEOUT (2m) must be less than IDLE_TIMEOUT (1m)",
+		},
+		{"LessThan zero and a duration", LessThan("MIN", "MAX"), map[string]string{"MIN": "0", "MAX": "10s"}, ""},
+		{"LessThan zero and a number", LessThan("MIN", "MAX"), map[string]string{"MIN": "0", "MAX": "10"}, ""},
+		{"LessThan numbers", LessThan("MIN", "MAX"), map[string]string{"MIN": "1.5", "MAX": "10"}, ""},
+		{"LessThan equal", LessThan("MIN", "MAX"), map[string]string{"MIN": "10", "MAX": "10"}, "MIN (10) must be less than MAX (10)"},
+		{
+			"LessThan invalid", LessThan("MIN", "MAX"),
+			map[string]string{"MIN": "low", "MAX": "10"},
+			`cannot compare MIN and MAX: "low" is neither a duration nor a number`,
+		},
+		{
+			"LessThan mixed", LessThan("MIN", "MAX"),
+			map[string]string{"MIN": "5", "MAX": "10s"},
+			`cannot compare MIN and MAX: "5" and "10s" are not both durations or both numbers`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := LoadSnapshot(WithEnvFile(emptyEnvFile(t)), WithOverrides(tt.values))
+			if err != nil {
+				t.Fatalf("LoadSnapshot: %v", err)
+			}
+			err = tt.validator.Validate(c)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Source loads a config file stored in S3. Objects encrypted with SSE-S3
+// or SSE-KMS are decrypted by S3 as long as the credentials may use the
+// key. Watching polls with If-None-Match, so unchanged objects are not
+// downloaded again.
+type S3Source struct {
+	Bucket string
+	Key    string
+	// Format of the object; derived from Key when empty.
+	Format Format
+	Region string
+	// Endpoint switches to path-style requests against an S3-compatible
+	// service.
+	Endpoint string
+	// PollInterval and Timeout default to 1m and 30s when not positive.
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Client       *http.Client
+
+	once sync.Once
+	aws  *awsClient
+
+	mu     sync.Mutex
+	etag   string
+	cached map[string]string
+}
+
+const (
+	defaultS3PollInterval = time.Minute
+	defaultS3Timeout      = 30 * time.Second
+)
+
+func NewS3Source(bucket, key string) *S3Source {
+	return &S3Source{
+		Bucket:       bucket,
+		Key:          key,
+		PollInterval: defaultS3PollInterval,
+		Timeout:      defaultS3Timeout,
+	}
+}
+
+func (s *S3Source) Load() (map[string]string, error) {
+	values, _, err := s.fetch(context.Background())
+	return values, err
+}
+
+func (s *S3Source) Watch(ctx context.Context, changed func()) error {
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, modified, err := s.fetch(ctx)
+			if err != nil {
+				logWatchError("S3", s.Bucket+"/"+s.Key, err)
+				continue
+			}
+			if modified {
+				changed()
+			}
+		}
+	}
+}
+
+func (s *S3Source) fetch(ctx context.Context) (map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	s.once.Do(func() { s.aws = newAWSClient(s.Region, s.Endpoint, s.Client) })
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	resp, err := s.aws.do(ctx, req, "s3", nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("error fetching s3://%s/%s: %w", s.Bucket, s.Key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && s.cached != nil {
+		return copyEnvs(s.cached), false, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading s3://%s/%s: %w", s.Bucket, s.Key, err)
+	}
+
+	format := s.Format
+	if format == "" {
+		format = formatForFile(s.Key)
+	}
+	values, err := decodeFormat(format, data)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing s3://%s/%s: %w", s.Bucket, s.Key, err)
+	}
+
+	s.etag = resp.Header.Get("ETag")
+	s.cached = values
+	return copyEnvs(values), true, nil
+}
+
+func (s *S3Source) objectURL() string {
+	segments := strings.Split(strings.TrimPrefix(s.Key, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = s3EscapeSegment(segment)
+	}
+	key := strings.Join(segments, "/")
+
+	if s.aws.endpoint != "" {
+		return s.aws.endpoint + "/" + s.Bucket + "/" + key
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.Bucket, s.aws.region, key)
+}
+
+// s3EscapeSegment percent-encodes a key segment as SigV4 canonical URIs
+// require: everything but letters, digits and -._~ is escaped, including
+// the +=:@$ that url.PathEscape leaves alone and S3 would then sign
+// differently.
+func s3EscapeSegment(segment string) string {
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func (s *S3Source) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultS3PollInterval)
+}
+
+func (s *S3Source) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultS3Timeout)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestS3EscapeSegment(t *testing.T) {
+	tests := []struct {
+		segment, want string
+	}{
+		{"config.yaml", "config.yaml"},
+		{"my-app_v1~2.json", "my-app_v1~2.json"},
+		{"a b", "a%20b"},
+		{"a+b=c:d@e$f", "a%2Bb%3Dc%3Ad%40e%24f"},
+		{"100%", "100%25"},
+		{"ünï", "%C3%BCn%C3%AF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.segment, func(t *testing.T) {
+			if got := s3EscapeSegment(tt.segment); got != tt.want {
+				t.Errorf("s3EscapeSegment(%q) = %q, want %q", tt.segment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestS3Source(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	var requestURI string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("port: 8080\n"))
+	}))
+	defer srv.Close()
+
+	// A struct literal, without the constructor's durations.
+	s := &S3Source{Bucket: "bucket", Key: "envs/prod+eu=1/app@v2.yaml", Region: "eu-west-1", Endpoint: srv.URL}
+	tests := []struct {
+		name         string
+		wantModified bool
+	}{
+		{"first fetch", true},
+		{"not modified", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, modified, err := s.fetch(context.Background())
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if want := map[string]string{"PORT": "8080"}; !maps.Equal(values, want) || modified != tt.wantModified {
+				t.Errorf("fetch = %v, %v, want %v, %v", values, modified, want, tt.wantModified)
+			}
+			if want := "/bucket/envs/prod%2Beu%3D1/app%40v2.yaml"; requestURI != want {
+				t.Errorf("request URI = %q, want %q", requestURI, want)
+			}
+		})
+	}
+}
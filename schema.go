@@ -0,0 +1,512 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/netip"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithJSONSchema validates the resolved configuration against a JSON
+// Schema document during NewConfig and Load.
+func WithJSONSchema(schema []byte) Option {
+	return WithValidator(JSONSchema(schema))
+}
+
+// WithJSONSchemaFile is WithJSONSchema with the schema read from a file.
+func WithJSONSchemaFile(file string) Option {
+	return WithValidator(ValidatorFunc(func(c *Config) error {
+		schema, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading JSON Schema: %w", err)
+		}
+		return JSONSchema(schema).Validate(c)
+	}))
+}
+
+// JSONSchema returns a validator checking the configuration against a JSON
+// Schema. The keys of the configuration form the document: top-level
+// properties name keys such as PORT, nested objects map like structured
+// files (database.url is DATABASE_URL), arrays of scalars split like
+// GetStrings and arrays of objects read indexed keys (UPSTREAM_0_URL).
+// Values are converted to the types the schema expects before checking.
+//
+// The common keywords are supported: type, enum, const, the numeric and
+// string bounds, pattern, format, required, properties, items, the array
+// bounds, allOf, anyOf, oneOf, not and local $ref. additionalProperties is
+// ignored, since the environment always holds unrelated variables. Errors
+// carry JSON Pointer paths, e.g. "/database/port: 70000 is greater than
+// the maximum 65535"; values of properties marked writeOnly are redacted,
+// and so are the passwords of URLs and connection strings.
+func JSONSchema(schema []byte) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		var root any
+		if err := json.Unmarshal(schema, &root); err != nil {
+			return fmt.Errorf("invalid JSON Schema: %w", err)
+		}
+		s := &schemaValidator{c: c, root: root}
+		doc, _ := s.build(root, "", 0)
+		return errors.Join(s.validate(doc, root, "", 0)...)
+	})
+}
+
+// maxSchemaDepth bounds $ref chains and nesting.
+const maxSchemaDepth = 64
+
+type schemaValidator struct {
+	c    *Config
+	root any
+}
+
+type schemaError struct {
+	path, message string
+}
+
+func (e *schemaError) Error() string {
+	path := e.path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.message
+}
+
+// resolve follows $ref and turns boolean schemas into objects.
+func (s *schemaValidator) resolve(schema any) map[string]any {
+	for depth := 0; depth < maxSchemaDepth; depth++ {
+		switch v := schema.(type) {
+		case bool:
+			if v {
+				return map[string]any{}
+			}
+			return map[string]any{"not": map[string]any{}}
+		case map[string]any:
+			ref, ok := v["$ref"].(string)
+			if !ok {
+				return v
+			}
+			schema = s.lookupRef(ref)
+		default:
+			return map[string]any{}
+		}
+	}
+	return map[string]any{}
+}
+
+func (s *schemaValidator) lookupRef(ref string) any {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return true
+	}
+	node := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := node.(type) {
+		case map[string]any:
+			node = v[token]
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return true
+			}
+			node = v[i]
+		default:
+			return true
+		}
+	}
+	return node
+}
+
+// types lists the types a schema allows, including those of its
+// subschemas, to decide how to read a value.
+func (s *schemaValidator) types(schema any, depth int) map[string]bool {
+	types := make(map[string]bool)
+	if depth > maxSchemaDepth {
+		return types
+	}
+	m := s.resolve(schema)
+	switch t := m["type"].(type) {
+	case string:
+		types[t] = true
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types[name] = true
+			}
+		}
+	}
+	if _, ok := m["properties"]; ok {
+		types["object"] = true
+	}
+	// Numeric keywords only apply to numbers, so a schema using them
+	// without a type still expects one.
+	for _, keyword := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"} {
+		if _, ok := m[keyword]; ok {
+			types["number"] = true
+		}
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := m[keyword].([]any)
+		for _, sub := range list {
+			for name := range s.types(sub, depth+1) {
+				types[name] = true
+			}
+		}
+	}
+	return types
+}
+
+// build reads the part of the configuration a schema describes below key,
+// reporting whether any of it is set.
+func (s *schemaValidator) build(schema any, key string, depth int) (any, bool) {
+	if depth > maxSchemaDepth {
+		return nil, false
+	}
+	m := s.resolve(schema)
+	types := s.types(m, depth)
+
+	switch {
+	case types["object"]:
+		doc := make(map[string]any)
+		for _, props := range s.allProperties(m, depth) {
+			for name, sub := range props {
+				if child, ok := s.build(sub, joinKey(key, name), depth+1); ok {
+					doc[name] = child
+				}
+			}
+		}
+		return doc, len(doc) > 0
+	case types["array"] && key != "":
+		items := m["items"]
+		if s.types(items, depth+1)["object"] {
+			var list []any
+			for i := 0; ; i++ {
+				item, ok := s.build(items, joinKey(key, strconv.Itoa(i)), depth+1)
+				if !ok {
+					break
+				}
+				list = append(list, item)
+			}
+			return list, list != nil
+		}
+		value := s.c.Get(key)
+		if value == "" {
+			return nil, false
+		}
+		itemTypes := s.types(items, depth+1)
+		list := []any{}
+		for _, item := range s.c.splitList(value) {
+			list = append(list, coerceSchemaValue(item, itemTypes))
+		}
+		return list, true
+	case key == "":
+		return nil, false
+	}
+
+	value := s.c.Get(key)
+	if value == "" {
+		return nil, false
+	}
+	return coerceSchemaValue(value, types), true
+}
+
+// allProperties collects the properties of a schema and of its allOf,
+// anyOf and oneOf branches, so that keys named only in a branch are read.
+func (s *schemaValidator) allProperties(m map[string]any, depth int) []map[string]any {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	var all []map[string]any
+	if props, ok := m["properties"].(map[string]any); ok {
+		all = append(all, props)
+	}
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := m[keyword].([]any)
+		for _, sub := range list {
+			all = append(all, s.allProperties(s.resolve(sub), depth+1)...)
+		}
+	}
+	return all
+}
+
+func coerceSchemaValue(value string, types map[string]bool) any {
+	if types["boolean"] {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	if types["integer"] || types["number"] {
+		if n, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+			return n
+		}
+	}
+	return value
+}
+
+func (s *schemaValidator) validate(value any, schema any, path string, depth int) []error {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	m := s.resolve(schema)
+	// Values of writeOnly properties, such as passwords, stay out of errors,
+	// as do the passwords of connection strings.
+	shown := describeValue(value)
+	if s, ok := value.(string); ok {
+		shown = describeValue(redactCredentials(s))
+	}
+	if writeOnly, _ := m["writeOnly"].(bool); writeOnly {
+		shown = redacted
+	}
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, &schemaError{path: path, message: fmt.Sprintf(format, args...)})
+	}
+
+	if types := typeList(m["type"]); len(types) > 0 && !matchesType(value, types) {
+		fail("%s is not of type %s", shown, strings.Join(types, " or "))
+		return errs
+	}
+	if enum, ok := m["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%s is not one of %s", shown, describeValue(enum))
+		}
+	}
+	if constant, ok := m["const"]; ok && !reflect.DeepEqual(value, constant) {
+		fail("%s is not %s", shown, describeValue(constant))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if min, ok := m["minimum"].(float64); ok && v < min {
+			fail("%s is less than the minimum %s", shown, describeValue(min))
+		}
+		if max, ok := m["maximum"].(float64); ok && v > max {
+			fail("%s is greater than the maximum %s", shown, describeValue(max))
+		}
+		if min, ok := m["exclusiveMinimum"].(float64); ok && v <= min {
+			fail("%s is not greater than %s", shown, describeValue(min))
+		}
+		if max, ok := m["exclusiveMaximum"].(float64); ok && v >= max {
+			fail("%s is not less than %s", shown, describeValue(max))
+		}
+		if step, ok := m["multipleOf"].(float64); ok && step > 0 {
+			if q := v / step; q != math.Trunc(q) {
+				fail("%s is not a multiple of %s", shown, describeValue(step))
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := m["minLength"].(float64); ok && length < min {
+			fail("%s is shorter than %s characters", shown, describeValue(min))
+		}
+		if max, ok := m["maxLength"].(float64); ok && length > max {
+			fail("%s is longer than %s characters", shown, describeValue(max))
+		}
+		if pattern, ok := m["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				fail("invalid pattern %q in schema: %v", pattern, err)
+			} else if !re.MatchString(v) {
+				fail("%s does not match %q", shown, pattern)
+			}
+		}
+		if format, ok := m["format"].(string); ok {
+			if err := checkFormat(format, v); err != nil {
+				fail("%s is not a valid %s", shown, format)
+			}
+		}
+	case map[string]any:
+		required, _ := m["required"].([]any)
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, present := v[name]; !present {
+					errs = append(errs, &schemaError{path: path + "/" + escapePointer(name), message: "is required but not set"})
+				}
+			}
+		}
+		props, _ := m["properties"].(map[string]any)
+		for _, name := range sortedKeys(props) {
+			if child, present := v[name]; present {
+				errs = append(errs, s.validate(child, props[name], path+"/"+escapePointer(name), depth+1)...)
+			}
+		}
+	case []any:
+		if min, ok := m["minItems"].(float64); ok && float64(len(v)) < min {
+			fail("has fewer than %s items", describeValue(min))
+		}
+		if max, ok := m["maxItems"].(float64); ok && float64(len(v)) > max {
+			fail("has more than %s items", describeValue(max))
+		}
+		if unique, _ := m["uniqueItems"].(bool); unique {
+			for i := range v {
+				for j := 0; j < i; j++ {
+					if reflect.DeepEqual(v[i], v[j]) {
+						fail("items %d and %d are equal", j, i)
+					}
+				}
+			}
+		}
+		if items, ok := m["items"]; ok {
+			for i, item := range v {
+				errs = append(errs, s.validate(item, items, path+"/"+strconv.Itoa(i), depth+1)...)
+			}
+		}
+	}
+
+	if list, ok := m["allOf"].([]any); ok {
+		for _, sub := range list {
+			errs = append(errs, s.validate(value, sub, path, depth+1)...)
+		}
+	}
+	if list, ok := m["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range list {
+			if len(s.validate(value, sub, path, depth+1)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("does not match any of the allowed schemas")
+		}
+	}
+	if list, ok := m["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range list {
+			if len(s.validate(value, sub, path, depth+1)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			fail("matches %d of the oneOf schemas instead of exactly one", matches)
+		}
+	}
+	if not, ok := m["not"]; ok && len(s.validate(value, not, path, depth+1)) == 0 {
+		fail("must not match the schema in not")
+	}
+	return errs
+}
+
+func typeList(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, item := range t {
+			if name, ok := item.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesType(value any, types []string) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case string:
+			if t == "string" {
+				return true
+			}
+		case float64:
+			if t == "number" || t == "integer" && v == math.Trunc(v) {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case nil:
+			if t == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkFormat checks the formats that matter for configuration; others are
+// treated as annotations, as the specification allows.
+func checkFormat(format, value string) error {
+	var err error
+	switch format {
+	case "uri", "url":
+		_, err = parseURL(value)
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "hostname":
+		if !isHostname(value) {
+			err = errors.New("invalid hostname")
+		}
+	case "ipv4":
+		var addr netip.Addr
+		if addr, err = netip.ParseAddr(value); err == nil && !addr.Is4() {
+			err = errors.New("not IPv4")
+		}
+	case "ipv6":
+		var addr netip.Addr
+		if addr, err = netip.ParseAddr(value); err == nil && !addr.Is6() {
+			err = errors.New("not IPv6")
+		}
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	case "date":
+		_, err = time.Parse(time.DateOnly, value)
+	}
+	return err
+}
+
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// emptyEnvFile returns an empty .env file, so tests read only the options
+// they pass.
+func emptyEnvFile(t *testing.T) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestJSONSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		values map[string]string
+		want   []string
+	}{
+		{
+			name:   "valid",
+			schema: `{"properties": {"APP_PORT": {"type": "integer", "minimum": 1, "maximum": 65535}}}`,
+			values: map[string]string{"APP_PORT": "8080"},
+		},
+		{
+			name:   "unset keys are not checked",
+			schema: `{"properties": {"APP_PORT": {"type": "integer"}}}`,
+		},
+		{
+			name:   "type",
+			schema: `{"properties": {"APP_PORT": {"type": "integer"}, "APP_DEBUG": {"type": "boolean"}}}`,
+			values: map[string]string{"APP_PORT": "80.5", "APP_DEBUG": "maybe"},
+			want: []string{
+				`/APP_DEBUG: "maybe" is not of type boolean`,
+				`/APP_PORT: 80.5 is not of type integer`,
+			},
+		},
+		{
+			name:   "numeric bounds",
+			schema: `{"properties": {"A": {"minimum": 10}, "B": {"maximum": 10}, "C": {"exclusiveMinimum": 10}, "D": {"exclusiveMaximum": 10}, "E": {"multipleOf": 5}}}`,
+			values: map[string]string{"A": "9", "B": "11", "C": "10", "D": "10", "E": "12"},
+			want: []string{
+				`/A: 9 is less than the minimum 10`,
+				`/B: 11 is greater than the maximum 10`,
+				`/C: 10 is not greater than 10`,
+				`/D: 10 is not less than 10`,
+				`/E: 12 is not a multiple of 5`,
+			},
+		},
+		{
+			name:   "string bounds and pattern",
+			schema: `{"properties": {"A": {"type": "string", "minLength": 3}, "B": {"type": "string", "maxLength": 2}, "C": {"type": "string", "pattern": "^[a-z]+$"}}}`,
+			values: map[string]string{"A": "ab", "B": "äöü", "C": "ABC"},
+			want: []string{
+				`/A: "ab" is shorter than 3 characters`,
+				`/B: "äöü" is longer than 2 characters`,
+				`/C: "ABC" does not match "^[a-z]+$"`,
+			},
+		},
+		{
+			name:   "enum and const",
+			schema: `{"properties": {"MODE": {"enum": ["dev", "prod"]}, "WORKERS": {"type": "integer", "const": 4}}}`,
+			values: map[string]string{"MODE": "test", "WORKERS": "3"},
+			want: []string{
+				`/MODE: "test" is not one of ["dev","prod"]`,
+				`/WORKERS: 3 is not 4`,
+			},
+		},
+		{
+			name:   "formats",
+			schema: `{"properties": {"A": {"format": "email"}, "B": {"format": "ipv4"}, "C": {"format": "date"}, "D": {"format": "uuid"}}}`,
+			values: map[string]string{"A": "not-an-email", "B": "::1", "C": "2024-13-01", "D": "anything"},
+			want: []string{
+				`/A: "not-an-email" is not a valid email`,
+				`/B: "::1" is not a valid ipv4`,
+				`/C: "2024-13-01" is not a valid date`,
+			},
+		},
+		{
+			name:   "nested objects and required",
+			schema: `{"required": ["database"], "properties": {"database": {"required": ["url", "host"], "properties": {"url": {"type": "string"}, "port": {"type": "integer", "maximum": 65535}}}}}`,
+			values: map[string]string{"DATABASE_URL": "postgres://db", "DATABASE_PORT": "70000"},
+			want: []string{
+				`/database/host: is required but not set`,
+				`/database/port: 70000 is greater than the maximum 65535`,
+			},
+		},
+		{
+			name:   "required object with no keys set",
+			schema: `{"required": ["database"], "properties": {"database": {"properties": {"url": {"type": "string"}}}}}`,
+			want:   []string{`/database: is required but not set`},
+		},
+		{
+			name:   "array of scalars",
+			schema: `{"properties": {"PORTS": {"type": "array", "minItems": 3, "uniqueItems": true, "items": {"type": "integer"}}}}`,
+			values: map[string]string{"PORTS": "80,x,80"},
+			want: []string{
+				`/PORTS: items 0 and 2 are equal`,
+				`/PORTS/1: "x" is not of type integer`,
+			},
+		},
+		{
+			name:   "array of objects",
+			schema: `{"properties": {"UPSTREAM": {"type": "array", "maxItems": 1, "items": {"required": ["url"], "properties": {"url": {"format": "uri"}, "weight": {"type": "integer"}}}}}}`,
+			values: map[string]string{"UPSTREAM_0_URL": "http://a", "UPSTREAM_1_WEIGHT": "2"},
+			want: []string{
+				`/UPSTREAM: has more than 1 items`,
+				`/UPSTREAM/1/url: is required but not set`,
+			},
+		},
+		{
+			name:   "allOf anyOf oneOf not",
+			schema: `{"properties": {"A": {"allOf": [{"minimum": 1}, {"maximum": 5}]}, "B": {"anyOf": [{"type": "boolean"}, {"type": "integer"}]}, "C": {"oneOf": [{"type": "integer"}, {"minimum": 0}]}, "D": {"not": {"enum": ["root"]}}}}`,
+			values: map[string]string{"A": "7", "B": "yes", "C": "3", "D": "root"},
+			want: []string{
+				`/A: 7 is greater than the maximum 5`,
+				`/B: does not match any of the allowed schemas`,
+				`/C: matches 2 of the oneOf schemas instead of exactly one`,
+				`/D: must not match the schema in not`,
+			},
+		},
+		{
+			name:   "keys named only in a branch are read",
+			schema: `{"anyOf": [{"required": ["TOKEN"], "properties": {"TOKEN": {"minLength": 8}}}, {"required": ["CERT"]}]}`,
+			values: map[string]string{"TOKEN": "long enough"},
+		},
+		{
+			name:   "no branch matches",
+			schema: `{"anyOf": [{"required": ["TOKEN"], "properties": {"TOKEN": {"minLength": 8}}}, {"required": ["CERT"]}]}`,
+			values: map[string]string{"TOKEN": "short"},
+			want:   []string{`/: does not match any of the allowed schemas`},
+		},
+		{
+			name:   "numeric keywords ignore other values",
+			schema: `{"properties": {"A": {"minimum": 10}}}`,
+			values: map[string]string{"A": "auto"},
+		},
+		{
+			name:   "local ref",
+			schema: `{"$defs": {"port": {"type": "integer", "maximum": 65535}}, "properties": {"HTTP_PORT": {"$ref": "#/$defs/port"}, "ADMIN_PORT": {"$ref": "#/$defs/port"}}}`,
+			values: map[string]string{"HTTP_PORT": "99999", "ADMIN_PORT": "8081"},
+			want:   []string{`/HTTP_PORT: 99999 is greater than the maximum 65535`},
+		},
+		{
+			name:   "recursive ref terminates",
+			schema: `{"$defs": {"loop": {"$ref": "#/$defs/loop"}}, "properties": {"A": {"$ref": "#/$defs/loop"}}}`,
+			values: map[string]string{"A": "1"},
+		},
+		{
+			name:   "boolean schemas",
+			schema: `{"properties": {"A": true, "B": false}}`,
+			values: map[string]string{"A": "1", "B": "1"},
+			want:   []string{`/B: must not match the schema in not`},
+		},
+		{
+			name:   "writeOnly values are redacted",
+			schema: `{"properties": {"API_TOKEN": {"writeOnly": true, "minLength": 32}}}`,
+			values: map[string]string{"API_TOKEN": "hunter2"},
+			want:   []string{`/API_TOKEN: ` + redacted + ` is shorter than 32 characters`},
+		},
+		{
+			name:   "URL passwords are redacted",
+			schema: `{"properties": {"DATABASE_URL": {"pattern": "^mysql://"}}}`,
+			values: map[string]string{"DATABASE_URL": "postgres://app:s3cret@db:5432/app"},
+			want:   []string{`/DATABASE_URL: "postgres://app:xxxxx@db:5432/app" does not match "^mysql://"`},
+		},
+		{
+			name:   "DSN passwords are redacted",
+			schema: `{"properties": {"MYSQL_DSN": {"maxLength": 10}}}`,
+			values: map[string]string{"MYSQL_DSN": "app:s3cret@tcp(db:3306)/app"},
+			want:   []string{`/MYSQL_DSN: "app:xxxxx@tcp(db:3306)/app" is longer than 10 characters`},
+		},
+		{
+			name:   "invalid pattern",
+			schema: `{"properties": {"A": {"type": "string", "pattern": "("}}}`,
+			values: map[string]string{"A": "x"},
+			want:   []string{"/A: invalid pattern \"(\" in schema: error parsing regexp: missing closing ): `(`"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := LoadSnapshot(
+				WithEnvFile(emptyEnvFile(t)),
+				WithOverrides(tt.values),
+			)
+			if err != nil {
+				t.Fatalf("LoadSnapshot: %v", err)
+			}
+			err = JSONSchema([]byte(tt.schema)).Validate(c)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate = nil, want %q", tt.want)
+			}
+			if got, want := err.Error(), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("Validate =\n%s\nwant\n%s", got, want)
+			}
+			if strings.Contains(err.Error(), "s3cret") || strings.Contains(err.Error(), "hunter2") {
+				t.Errorf("Validate leaks a secret: %v", err)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaInvalid(t *testing.T) {
+	c, err := LoadSnapshot(WithEnvFile(emptyEnvFile(t)))
+	if err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	err = JSONSchema([]byte(`{"properties":`)).Validate(c)
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid JSON Schema: ") {
+		t.Errorf("Validate = %v, want an invalid JSON Schema error", err)
+	}
+}
+
+func TestRedactCredentials(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"plain", "hello", "hello"},
+		{"url without password", "https://example.com/path", "https://example.com/path"},
+		{"url", "postgres://app:s3cret@db/app", "postgres://app:xxxxx@db/app"},
+		{"mysql dsn", "app:s3cret@tcp(db:3306)/app", "app:xxxxx@tcp(db:3306)/app"},
+		{"unparsable url", "redis://:pa%zz@cache:6379", "redis://:xxxxx@cache:6379"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactCredentials(tt.value); got != tt.want {
+				t.Errorf("redactCredentials(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SecretsManagerSource fetches a secret from AWS Secrets Manager and
+// explodes its JSON fields into keys. A secret that is not a JSON object is
+// exposed under Key. Values are cached for CacheTTL; watching the source
+// polls for a new AWSCURRENT version so rotations trigger a reload.
+type SecretsManagerSource struct {
+	SecretID string
+	// Key receives the secret when it is a plain string.
+	Key          string
+	VersionStage string
+	Region       string
+	Endpoint     string
+	CacheTTL     time.Duration
+	// PollInterval and Timeout default to 5m and 30s when not positive.
+	PollInterval time.Duration
+	Timeout      time.Duration
+	Client       *http.Client
+
+	once sync.Once
+	aws  *awsClient
+
+	mu        sync.Mutex
+	cached    map[string]string
+	versionID string
+	fetchedAt time.Time
+}
+
+const (
+	defaultSecretsManagerPollInterval = 5 * time.Minute
+	defaultSecretsManagerTimeout      = 30 * time.Second
+)
+
+func NewSecretsManagerSource(secretID string) *SecretsManagerSource {
+	return &SecretsManagerSource{
+		SecretID:     secretID,
+		VersionStage: "AWSCURRENT",
+		CacheTTL:     5 * time.Minute,
+		PollInterval: defaultSecretsManagerPollInterval,
+		Timeout:      defaultSecretsManagerTimeout,
+	}
+}
+
+func (s *SecretsManagerSource) Load() (map[string]string, error) {
+	s.mu.Lock()
+	if s.cached != nil && time.Since(s.fetchedAt) < s.CacheTTL {
+		values := copyEnvs(s.cached)
+		s.mu.Unlock()
+		return values, nil
+	}
+	s.mu.Unlock()
+
+	values, _, err := s.fetch(context.Background())
+	return values, err
+}
+
+func (s *SecretsManagerSource) Watch(ctx context.Context, changed func()) error {
+	ticker := time.NewTicker(s.pollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			_, rotated, err := s.fetch(ctx)
+			if err != nil {
+				logWatchError("Secrets Manager", s.SecretID, err)
+				continue
+			}
+			if rotated {
+				changed()
+			}
+		}
+	}
+}
+
+func (s *SecretsManagerSource) fetch(ctx context.Context) (map[string]string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout())
+	defer cancel()
+
+	s.once.Do(func() { s.aws = newAWSClient(s.Region, s.Endpoint, s.Client) })
+
+	in := map[string]string{"SecretId": s.SecretID}
+	if s.VersionStage != "" {
+		in["VersionStage"] = s.VersionStage
+	}
+	var out struct {
+		SecretString string
+		VersionId    string
+	}
+	if err := s.aws.callJSON(ctx, "secretsmanager", "secretsmanager.GetSecretValue", in, &out); err != nil {
+		return nil, false, fmt.Errorf("error reading secret %s: %w", s.SecretID, err)
+	}
+
+	values := make(map[string]string)
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(out.SecretString), &fields); err == nil {
+		for field, value := range fields {
+			values[field] = scalarString(value)
+		}
+	} else if s.Key != "" {
+		values[s.Key] = out.SecretString
+	} else {
+		return nil, false, fmt.Errorf("secret %s is not a JSON object and no Key is set", s.SecretID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rotated := s.versionID != "" && s.versionID != out.VersionId
+	s.cached = values
+	s.versionID = out.VersionId
+	s.fetchedAt = time.Now()
+	return copyEnvs(values), rotated, nil
+}
+
+func (s *SecretsManagerSource) pollInterval() time.Duration {
+	return positiveOr(s.PollInterval, defaultSecretsManagerPollInterval)
+}
+
+func (s *SecretsManagerSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultSecretsManagerTimeout)
+}
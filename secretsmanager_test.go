@@ -0,0 +1,95 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecretsManagerSource(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	tests := []struct {
+		name        string
+		key         string
+		responses   []string
+		want        map[string]string
+		wantRotated []bool
+		wantErr     string
+	}{
+		{
+			name:        "json fields",
+			responses:   []string{`{"username":"app","port":5432,"tls":true}`},
+			want:        map[string]string{"username": "app", "port": "5432", "tls": "true"},
+			wantRotated: []bool{false},
+		},
+		{
+			name:        "plain string under Key",
+			key:         "API_TOKEN",
+			responses:   []string{"s3cret"},
+			want:        map[string]string{"API_TOKEN": "s3cret"},
+			wantRotated: []bool{false},
+		},
+		{
+			name:      "plain string without Key",
+			responses: []string{"s3cret"},
+			wantErr:   "is not a JSON object and no Key is set",
+		},
+		{
+			name:        "rotation",
+			key:         "API_TOKEN",
+			responses:   []string{"v1", "v1", "v2"},
+			want:        map[string]string{"API_TOKEN": "v2"},
+			wantRotated: []bool{false, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("X-Amz-Target"); got != "secretsmanager.GetSecretValue" {
+					t.Errorf("X-Amz-Target = %q", got)
+				}
+				var in map[string]string
+				json.NewDecoder(r.Body).Decode(&in)
+				if in["SecretId"] != "prod/app" || in["VersionStage"] != "" {
+					t.Errorf("request = %v", in)
+				}
+				secret := tt.responses[call]
+				call++
+				json.NewEncoder(w).Encode(map[string]string{"SecretString": secret, "VersionId": secret})
+			}))
+			defer srv.Close()
+
+			// A struct literal, without the constructor's durations.
+			s := &SecretsManagerSource{SecretID: "prod/app", Key: tt.key, Region: "us-east-1", Endpoint: srv.URL}
+			var values map[string]string
+			for i := range tt.responses {
+				var rotated bool
+				var err error
+				values, rotated, err = s.fetch(context.Background())
+				if tt.wantErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+						t.Fatalf("fetch error = %v, want %q", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("fetch #%d: %v", i+1, err)
+				}
+				if rotated != tt.wantRotated[i] {
+					t.Errorf("fetch #%d rotated = %v, want %v", i+1, rotated, tt.wantRotated[i])
+				}
+			}
+			if !maps.Equal(values, tt.want) {
+				t.Errorf("fetch = %v, want %v", values, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decimal suffixes (KB, MB, ...) are powers of 1000 and binary suffixes
+// (KiB, MiB, ...) powers of 1024, as in Kubernetes resource quantities.
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+}
+
+// GetByteSize parses sizes such as 512MB or 2GiB into a number of bytes.
+func (c *Config) GetByteSize(key string) (int64, error) {
+	return getValue(c, key, parseByteSize)
+}
+
+func (c *Config) GetByteSizeWithFallback(key string, fallback int64) int64 {
+	return getValueWithFallback(c, key, "byte size", fallback, parseByteSize)
+}
+
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number, suffix := s[:i], strings.TrimSpace(s[i:])
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	unit, ok := byteSizeUnits[strings.ToLower(suffix)]
+	if !ok {
+		return 0, fmt.Errorf("unknown size suffix %q in %q (use B, KB, MB, GB, TB, PB or KiB, MiB, GiB, TiB, PiB)", suffix, s)
+	}
+	bytes := math.Round(value * unit)
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("byte size %q is too large", s)
+	}
+	return int64(bytes), nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// LoadSnapshot loads every source once and returns a snapshot of the result
+// for binding into several structs, e.g. HTTP, database and worker settings
+// owned by different modules. Like Load, it skips the built-in
+// DATABASE_URL and AUTH_SERVICE_URL checks.
+func LoadSnapshot(opts ...Option) (*Config, error) {
+	c, err := load(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := errors.Join(c.checkKeys(), c.runValidators(), c.environ().err(0)); err != nil {
+		return nil, err
+	}
+	c.environ().markLoaded()
+	return c.Snapshot(), nil
+}
+
+// Snapshot returns a copy of c that no longer follows the OS environment.
+// The environment and the files named by KEY_FILE variables are read once,
+// so binds from the snapshot agree with each other even if the process
+// environment changes in between.
+func (c *Config) Snapshot() *Config {
+	if c.frozen() {
+		return c
+	}
+	envs, defaults := c.resolvedEnvs()
+
+	frozen := make(map[string]string)
+	for _, name := range c.environ().names() {
+		frozen[name], _ = c.environ().lookup(name)
+	}
+	for name, value := range envs {
+		if value != "" || frozen[name] == "" {
+			frozen[name] = value
+		}
+	}
+
+	var fileKeys []string
+	for name := range frozen {
+		if key, ok := strings.CutSuffix(name, "_FILE"); ok && key != "" {
+			fileKeys = append(fileKeys, key)
+		}
+	}
+	for _, key := range fileKeys {
+		if value, exists := lookupFileEnv(c.environ(), envs, key); exists {
+			frozen[key] = value
+		}
+	}
+
+	snapshot := *c
+	snapshot.resolved = &resolvedEnvs{envs: frozen, defaults: defaults, environ: c.environ(), sourced: c.resolved.sourced, frozen: true}
+	return &snapshot
+}
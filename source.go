@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+type Source interface {
+	Load() (map[string]string, error)
+}
+
+// Watcher is implemented by sources that can report changes. Watch blocks
+// until ctx is done, calling changed whenever the source's values may have
+// changed.
+type Watcher interface {
+	Watch(ctx context.Context, changed func()) error
+}
+
+type SourceFunc func() (map[string]string, error)
+
+func (f SourceFunc) Load() (map[string]string, error) {
+	return f()
+}
+
+func WithSource(sources ...Source) Option {
+	return func(c *Config) {
+		for _, src := range sources {
+			if src != nil {
+				c.sources = append(c.sources, src)
+			}
+		}
+	}
+}
+
+func NewEnvFileSource(file string) Source {
+	return SourceFunc(func() (map[string]string, error) {
+		return loadEnv(file, false, defaultOverlay)
+	})
+}
+
+func (c *Config) loadSources(sources []Source) (map[string]string, error) {
+	envs := make(map[string]string)
+	for _, src := range sources {
+		values, err := src.Load()
+		if err != nil {
+			return nil, err
+		}
+		c.overlay(envs, values)
+	}
+	return envs, nil
+}
+
+// Watch reloads the configuration whenever one of its sources reports a
+// change and hands the fresh Config to onChange. It blocks until ctx is
+// done or a source fails to watch.
+func (c *Config) Watch(ctx context.Context, onChange func(*Config)) error {
+	var watchers []Watcher
+	for _, src := range c.sources {
+		if w, ok := src.(Watcher); ok {
+			watchers = append(watchers, w)
+		}
+	}
+	if len(watchers) == 0 {
+		return errors.New("no configured source supports watching")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	errs := make(chan error, len(watchers))
+	for _, w := range watchers {
+		go func(w Watcher) {
+			errs <- w.Watch(ctx, func() {
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			})
+		}(w)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+		case <-changes:
+			fresh, err := NewConfig(c.opts...)
+			if err != nil {
+				log.Printf("Warning: failed to reload configuration: %v", err)
+				continue
+			}
+			onChange(fresh)
+		}
+	}
+}
+
+func logWatchError(kind, name string, err error) {
+	log.Printf("Warning: failed to refresh %s source %s: %v", kind, name, err)
+}
+
+func copyEnvs(envs map[string]string) map[string]string {
+	values := make(map[string]string, len(envs))
+	mergeEnvs(values, envs)
+	return values
+}
+
+// positiveOr returns d, or fallback when d is not positive, so that a
+// source built as a struct literal behaves like one from its constructor.
+func positiveOr(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
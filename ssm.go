@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSMSource loads every parameter under Path from AWS Systems Manager
+// Parameter Store, decrypting SecureString values. The path is stripped,
+// so /myapp/prod/DATABASE_URL becomes DATABASE_URL.
+type SSMSource struct {
+	Path   string
+	Region string
+	// Endpoint overrides the regional service endpoint.
+	Endpoint string
+	// Timeout defaults to 30s when not positive.
+	Timeout time.Duration
+	Client  *http.Client
+
+	once sync.Once
+	aws  *awsClient
+}
+
+const defaultSSMTimeout = 30 * time.Second
+
+func NewSSMSource(path string) *SSMSource {
+	return &SSMSource{Path: path, Timeout: defaultSSMTimeout}
+}
+
+func (s *SSMSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	s.once.Do(func() { s.aws = newAWSClient(s.Region, s.Endpoint, s.Client) })
+	client := s.aws
+	prefix := strings.TrimSuffix(s.Path, "/") + "/"
+	envs := make(map[string]string)
+
+	var nextToken string
+	for {
+		in := map[string]any{
+			"Path":           s.Path,
+			"Recursive":      true,
+			"WithDecryption": true,
+			"MaxResults":     10,
+		}
+		if nextToken != "" {
+			in["NextToken"] = nextToken
+		}
+
+		var out struct {
+			Parameters []struct {
+				Name  string
+				Value string
+			}
+			NextToken string
+		}
+		if err := client.callJSON(ctx, "ssm", "AmazonSSM.GetParametersByPath", in, &out); err != nil {
+			return nil, fmt.Errorf("error reading SSM parameters under %s: %w", s.Path, err)
+		}
+
+		for _, param := range out.Parameters {
+			envs[normalizePathKey(strings.TrimPrefix(param.Name, prefix))] = param.Value
+		}
+		if out.NextToken == "" {
+			return envs, nil
+		}
+		nextToken = out.NextToken
+	}
+}
+
+func (s *SSMSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultSSMTimeout)
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WithStrict rejects keys starting with prefix that nothing reads, so a typo
+// such as APP_DATABSE_URL fails the load instead of leaving the setting at
+// its default. Known keys are those of the struct being loaded (Config for
+// NewConfig), keys declared with the With...Keys options, WithValue and
+// defaults, the keys the package reads itself such as APP_ENV and ENV_FILE,
+// and the keys listed in known, e.g. ones read only through Get. Tenant
+// overrides of known keys, see ForTenant, are known too. LoadSnapshot does
+// not check, since it cannot know the structs bound later.
+func WithStrict(prefix string, known ...string) Option {
+	return func(c *Config) {
+		c.strict = true
+		c.strictPrefix = prefix
+		c.knownKeys = append(c.knownKeys, known...)
+	}
+}
+
+// builtinKeys are read by the package itself rather than bound to fields.
+var builtinKeys = []string{"APP_ENV", "ENV_FILE", includeKey, "CREDENTIALS_DIRECTORY"}
+
+// checkUnknown reports every key with the strict prefix that neither t nor
+// a declaration accounts for.
+func (c *Config) checkUnknown(t reflect.Type, prefix string) error {
+	if !c.strict || t.Kind() != reflect.Struct {
+		return nil
+	}
+	keys, open := c.structKeys(t, prefix)
+	keys = append(keys, c.knownKeys...)
+	keys = append(keys, builtinKeys...)
+	for _, check := range c.checks {
+		keys = append(keys, check.key)
+	}
+	_, defaults := c.resolvedEnvs()
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+
+	known := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		known[c.foldKey(key)] = true
+	}
+	var unknown []string
+	for _, name := range c.keyNames() {
+		key := c.foldKey(name)
+		if !strings.HasPrefix(key, c.foldKey(c.strictPrefix)) || known[key] || known[strings.TrimSuffix(key, "_FILE")] {
+			continue
+		}
+		if hasAnyPrefix(key, open) || tenantKey(strings.TrimSuffix(key, "_FILE"), known) {
+			continue
+		}
+		known[key] = true
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+
+	var errs []error
+	for _, name := range unknown {
+		if match := closestKey(name, keys); match != "" {
+			errs = append(errs, fmt.Errorf("unknown key %s, did you mean %s?", name, match))
+		} else {
+			errs = append(errs, fmt.Errorf("unknown key %s", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// structKeys lists the keys t binds below prefix. Slices of sections and
+// prefix maps take any key under their prefix; those prefixes are returned
+// as open.
+func (c *Config) structKeys(t reflect.Type, prefix string) (keys, open []string) {
+	for _, f := range c.structFields(t, prefix) {
+		switch f.kind {
+		case sectionField, optionalSectionField:
+			section := f.field.Type
+			if f.kind == optionalSectionField {
+				section = section.Elem()
+			}
+			sectionKeys, sectionOpen := c.structKeys(section, f.key)
+			keys = append(keys, sectionKeys...)
+			open = append(open, sectionOpen...)
+		case sliceSectionField, prefixMapField:
+			open = append(open, c.foldKey(f.key))
+		default:
+			keys = append(keys, f.key)
+			keys = append(keys, f.aliases...)
+		}
+	}
+	return keys, open
+}
+
+func (c *Config) foldKey(key string) string {
+	if c.foldKeys {
+		return strings.ToUpper(key)
+	}
+	return key
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestKey returns the key nearest to name if it is close enough to be a
+// likely typo, or "".
+func closestKey(name string, keys []string) string {
+	best, bestDistance := "", len(name)/4+1
+	for _, key := range keys {
+		if d := editDistance(strings.ToUpper(name), strings.ToUpper(key)); d < bestDistance && d > 0 {
+			best, bestDistance = key, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b, counting a
+// swap of adjacent characters as one edit.
+func editDistance(a, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
@@ -0,0 +1,29 @@
+package config
+
+import "strings"
+
+// Sub returns a view of the keys under prefix, named without it, for
+// handing a subsystem its own settings and nothing else:
+// cfg.Sub("database").Get("HOST") reads DATABASE_HOST, and binding a struct
+// from the view binds its HOST field to DATABASE_HOST. The prefix is
+// normalized like a key, so "database" and "DATABASE_" are the same view.
+//
+// The view is a snapshot of c, as returned by Snapshot, holding no other
+// keys. The built-in fields such as DatabaseURL are left empty and
+// Warnings is empty; parsing options such as WithTimeLayouts or
+// WithKeySeparator carry over.
+func (c *Config) Sub(prefix string) *Config {
+	sep := c.keySep()
+	prefix = strings.TrimSuffix(normalizeKey(prefix), sep) + sep
+
+	return &Config{
+		resolved:        &resolvedEnvs{envs: c.prefixValues(prefix), frozen: true},
+		timeLayouts:     c.timeLayouts,
+		listSeparator:   c.listSeparator,
+		keySeparator:    c.keySeparator,
+		foldKeys:        c.foldKeys,
+		decoders:        c.decoders,
+		structValidator: c.structValidator,
+		regexps:         c.regexps,
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+)
+
+// tenantPrefix starts the keys overriding a setting for one tenant, e.g.
+// TENANT_ACME__RATE_LIMIT for RATE_LIMIT. tenantSep ends the tenant id; ids
+// may not contain it, so that TENANT_ACME_CORP__RATE_LIMIT cannot be read
+// as CORP_RATE_LIMIT of tenant ACME.
+const (
+	tenantPrefix = "TENANT_"
+	tenantSep    = "__"
+)
+
+// ForTenant returns the configuration seen by tenant id: every
+// TENANT_<ID>__KEY overrides KEY, and keys without a tenant value fall back
+// to the global one. The id is normalized like a key, so "acme-corp" reads
+// TENANT_ACME_CORP__RATE_LIMIT; ids that would contain "__" or start or end
+// with "_" are rejected. The view is a snapshot of c, as returned by
+// Snapshot, with the built-in fields resolved again; invalid tenant values
+// and failing validators are reported as by LoadSnapshot.
+func (c *Config) ForTenant(id string) (*Config, error) {
+	name := normalizeKey(id)
+	if name == "" || strings.Contains(name, tenantSep) || strings.HasPrefix(name, "_") || strings.HasSuffix(name, "_") {
+		return nil, fmt.Errorf("invalid tenant id %q", id)
+	}
+
+	tenant := *c.Snapshot()
+	envs, defaults := tenant.resolvedEnvs()
+	envs, sourced := maps.Clone(envs), maps.Clone(tenant.resolved.sourced)
+	for key, value := range c.prefixValues(tenantPrefix + name + tenantSep) {
+		delete(envs, key+"_FILE")
+		envs[key] = value
+		if sourced != nil {
+			sourced[key] = true
+		}
+	}
+	tenant.resolved = &resolvedEnvs{envs: envs, defaults: defaults, sourced: sourced, frozen: true}
+	tenant.setBuiltins(&environment{vars: map[string]string{}}, envs)
+	tenant.warnings = nil
+
+	errs := append(tenant.parseBuiltins(), tenant.checkKeys(), tenant.runValidators())
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// tenantKey reports whether name overrides one of known for a tenant.
+func tenantKey(name string, known map[string]bool) bool {
+	rest, ok := strings.CutPrefix(name, tenantPrefix)
+	if !ok {
+		return false
+	}
+	_, key, ok := strings.Cut(rest, tenantSep)
+	return ok && known[key]
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig builds a *tls.Config from TLS_CERT_FILE and TLS_KEY_FILE (the
+// certificate to present), TLS_CA_FILE (trusted for both server and client
+// certificates), TLS_MIN_VERSION (1.2 by default) and
+// TLS_INSECURE_SKIP_VERIFY. The files are read and parsed here, so call it
+// at startup to fail fast.
+func (c *Config) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if value := c.Get("TLS_MIN_VERSION"); value != "" {
+		version, ok := tlsVersions[strings.TrimPrefix(strings.ToUpper(value), "TLS")]
+		if !ok {
+			return nil, fmt.Errorf("invalid value for TLS_MIN_VERSION: %q is not one of 1.0, 1.1, 1.2, 1.3", value)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if value := c.Get("TLS_INSECURE_SKIP_VERIFY"); value != "" {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for TLS_INSECURE_SKIP_VERIFY: %w", err)
+		}
+		if skip {
+			log.Printf("Warning: TLS certificate verification is disabled by TLS_INSECURE_SKIP_VERIFY")
+		}
+		tlsConfig.InsecureSkipVerify = skip
+	}
+
+	certFile, keyFile := c.Get("TLS_CERT_FILE"), c.Get("TLS_KEY_FILE")
+	switch {
+	case certFile != "" && keyFile != "":
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading TLS certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case certFile != "":
+		return nil, fmt.Errorf("TLS_CERT_FILE is set but TLS_KEY_FILE is not")
+	case keyFile != "":
+		return nil, fmt.Errorf("TLS_KEY_FILE is set but TLS_CERT_FILE is not")
+	}
+
+	if caFile := c.Get("TLS_CA_FILE"); caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading TLS_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in TLS_CA_FILE %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+		tlsConfig.ClientCAs = pool
+	}
+	return tlsConfig, nil
+}
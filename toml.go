@@ -0,0 +1,17 @@
+package config
+
+import (
+	"github.com/BurntSushi/toml"
+)
+
+func WithTOMLFile(file string) Option {
+	return withFile("TOML", file, decodeTOML)
+}
+
+func decodeTOML(data []byte) (any, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
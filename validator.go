@@ -0,0 +1,226 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// Validator checks domain-specific rules on a loaded Config, e.g. that a
+// cache size fits the configured memory limit.
+type Validator interface {
+	Validate(c *Config) error
+}
+
+type ValidatorFunc func(c *Config) error
+
+func (f ValidatorFunc) Validate(c *Config) error {
+	return f(c)
+}
+
+// WithValidator attaches validators that NewConfig runs after its built-in
+// checks, in the order given. Load and LoadSnapshot run them as well. All
+// validators run even if one fails, and their errors are joined.
+func WithValidator(validators ...Validator) Option {
+	return func(c *Config) {
+		for _, v := range validators {
+			if v != nil {
+				c.validators = append(c.validators, v)
+			}
+		}
+	}
+}
+
+// WithKeyValidator checks a single key with fn when it is set, e.g. that
+// SMTP_HOST is not a bare IP address. Errors are reported as invalid values
+// of the key; fn may return a Warning to only warn.
+func WithKeyValidator(key string, fn func(value string) error) Option {
+	return WithValidator(ValidatorFunc(func(c *Config) error {
+		value := c.Get(key)
+		if value == "" {
+			return nil
+		}
+		if err := fn(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+		return nil
+	}))
+}
+
+func (c *Config) runValidators() error {
+	var errs []error
+	for _, v := range c.validators {
+		errs = append(errs, c.collectWarnings(v.Validate(c)))
+	}
+	return errors.Join(errs...)
+}
+
+// Warning marks a validation problem as non-fatal, e.g. DEBUG=true in
+// production. Validators return it, directly or joined with other errors,
+// to have it logged and kept in Warnings instead of failing the load.
+type Warning struct {
+	Err error
+}
+
+func (w *Warning) Error() string {
+	return w.Err.Error()
+}
+
+func (w *Warning) Unwrap() error {
+	return w.Err
+}
+
+// Warn downgrades every error of v to a Warning, e.g.
+// Warn(RequiredIf("TLS_ENABLED", "TLS_CA_FILE")).
+func Warn(v Validator) Validator {
+	return ValidatorFunc(func(c *Config) error {
+		return warnAll(v.Validate(c))
+	})
+}
+
+func warnAll(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, err := range e.Unwrap() {
+			errs = append(errs, warnAll(err))
+		}
+		return errors.Join(errs...)
+	default:
+		return &Warning{Err: err}
+	}
+}
+
+// Warnings returns the warnings raised by validators while loading c.
+func (c *Config) Warnings() []error {
+	return c.warnings
+}
+
+// collectWarnings records and logs the warnings within err and returns the
+// remaining fatal errors.
+func (c *Config) collectWarnings(err error) error {
+	var warning *Warning
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, err := range e.Unwrap() {
+			errs = append(errs, c.collectWarnings(err))
+		}
+		return errors.Join(errs...)
+	default:
+		if errors.As(err, &warning) {
+			log.Printf("Warning: %v", err)
+			c.warnings = append(c.warnings, err)
+			return nil
+		}
+		return err
+	}
+}
+
+// StructValidator validates bound structs through their tags. It is
+// satisfied by *validator.Validate from github.com/go-playground/validator,
+// so rules such as `validate:"required,url"` behave as elsewhere in a
+// service.
+type StructValidator interface {
+	Struct(s any) error
+}
+
+// WithStructValidator runs v on every struct after Bind or Load has filled
+// it. Errors name the key a field binds from rather than the Go field, e.g.
+// "invalid value for DB_HOST: failed hostname validation".
+func WithStructValidator(v StructValidator) Option {
+	return func(c *Config) {
+		c.structValidator = v
+	}
+}
+
+// validationFieldError is the part of validator.FieldError used to report
+// a failed rule, so that the validator package is not a dependency.
+type validationFieldError interface {
+	StructNamespace() string
+	Tag() string
+	Param() string
+}
+
+func (c *Config) validateStruct(v reflect.Value, prefix string) error {
+	if c.structValidator == nil {
+		return nil
+	}
+	err := c.structValidator.Struct(v.Interface())
+	if err == nil {
+		return nil
+	}
+
+	// validator.ValidationErrors is a slice of FieldError.
+	list := reflect.ValueOf(err)
+	if list.Kind() != reflect.Slice {
+		return err
+	}
+	var errs []error
+	for i := 0; i < list.Len(); i++ {
+		fieldErr, ok := list.Index(i).Interface().(validationFieldError)
+		if !ok {
+			return err
+		}
+		key := c.namespaceKey(v.Elem().Type(), prefix, fieldErr.StructNamespace())
+		switch rule := fieldErr.Tag(); {
+		case rule == "required":
+			errs = append(errs, fmt.Errorf("%s is not set", key))
+		case fieldErr.Param() != "":
+			errs = append(errs, fmt.Errorf("invalid value for %s: failed %s=%s validation", key, rule, fieldErr.Param()))
+		default:
+			errs = append(errs, fmt.Errorf("invalid value for %s: failed %s validation", key, rule))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// namespaceKey maps a validator namespace such as
+// Settings.Upstreams[1].URL to the key the field binds from, here
+// UPSTREAM_1_URL. Fields it cannot map keep their namespace.
+func (c *Config) namespaceKey(t reflect.Type, prefix, namespace string) string {
+	_, path, _ := strings.Cut(namespace, ".")
+	for path != "" {
+		var segment string
+		segment, path, _ = strings.Cut(path, ".")
+		name, index, _ := strings.Cut(strings.TrimSuffix(segment, "]"), "[")
+
+		var field *boundField
+		for _, f := range c.structFields(t, prefix) {
+			if f.field.Name == name {
+				field = &f
+				break
+			}
+		}
+		if field == nil {
+			return namespace
+		}
+
+		t = field.field.Type
+		switch field.kind {
+		case sectionField:
+			prefix = field.key
+		case optionalSectionField:
+			prefix, t = field.key, t.Elem()
+		case sliceSectionField:
+			if index == "" {
+				return field.key
+			}
+			prefix, t = field.key+index+c.keySep(), t.Elem()
+			if t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+		case prefixMapField:
+			return field.key + index
+		default:
+			return field.key
+		}
+	}
+	return namespace
+}
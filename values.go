@@ -0,0 +1,254 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Get returns the value of any key, resolved with the same precedence as
+// the built-in settings, or "" when it is not set.
+func (c *Config) Get(key string) string {
+	key = c.resolveKey(key)
+	envs, defaults := c.resolvedEnvs()
+	if c.frozen() {
+		if value := envs[key]; value != "" {
+			return value
+		}
+		return defaults[key]
+	}
+	return getEnvWithFallback(c.environ(), envs, key, defaults[key])
+}
+
+// WithCaseInsensitiveKeys lets lookups match a key that differs only in
+// case, e.g. database_url set by a platform for DATABASE_URL. Exact matches
+// still win.
+func WithCaseInsensitiveKeys() Option {
+	return func(c *Config) {
+		c.foldKeys = true
+	}
+}
+
+func (c *Config) resolveKey(key string) string {
+	if !c.foldKeys {
+		return key
+	}
+	if _, exists := c.lookup(key); exists {
+		return key
+	}
+
+	// Folding can change a name's length, as with the Kelvin sign, so the
+	// matched name is returned whole rather than sliced to key's length.
+	// A plain key wins over a KEY_FILE one.
+	names := c.keyNames()
+	slices.Sort(names)
+	for _, name := range names {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+	}
+	for _, name := range names {
+		if base, ok := strings.CutSuffix(name, "_FILE"); ok && strings.EqualFold(base, key) {
+			return base
+		}
+	}
+	return key
+}
+
+// keyNames lists every key a source, the OS environment or a default sets.
+// Names may repeat.
+func (c *Config) keyNames() []string {
+	envs, defaults := c.resolvedEnvs()
+	names := make([]string, 0, len(envs))
+	for name := range envs {
+		names = append(names, name)
+	}
+	if !c.frozen() {
+		names = append(names, c.environ().names()...)
+	}
+	for name := range defaults {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (c *Config) frozen() bool {
+	return c.resolved != nil && c.resolved.frozen
+}
+
+func (c *Config) environ() *environment {
+	if c.resolved == nil {
+		return nil
+	}
+	return c.resolved.environ
+}
+
+func (c *Config) resolvedEnvs() (envs, defaults map[string]string) {
+	if c.resolved == nil {
+		return nil, nil
+	}
+	return c.resolved.envs, c.resolved.defaults
+}
+
+func (c *Config) GetInt(key string) (int, error) {
+	return getValue(c, key, strconv.Atoi)
+}
+
+func (c *Config) GetIntWithFallback(key string, fallback int) int {
+	return getValueWithFallback(c, key, "integer", fallback, strconv.Atoi)
+}
+
+func (c *Config) GetInt64(key string) (int64, error) {
+	return getValue(c, key, parseInt64)
+}
+
+func (c *Config) GetInt64WithFallback(key string, fallback int64) int64 {
+	return getValueWithFallback(c, key, "integer", fallback, parseInt64)
+}
+
+func (c *Config) GetUint(key string) (uint, error) {
+	return getValue(c, key, parseUint)
+}
+
+func (c *Config) GetUintWithFallback(key string, fallback uint) uint {
+	return getValueWithFallback(c, key, "unsigned integer", fallback, parseUint)
+}
+
+func (c *Config) GetFloat64(key string) (float64, error) {
+	return getValue(c, key, parseFloat64)
+}
+
+func (c *Config) GetFloat64WithFallback(key string, fallback float64) float64 {
+	return getValueWithFallback(c, key, "float", fallback, parseFloat64)
+}
+
+func (c *Config) GetDuration(key string) (time.Duration, error) {
+	return getValue(c, key, time.ParseDuration)
+}
+
+func (c *Config) GetDurationWithFallback(key string, fallback time.Duration) time.Duration {
+	return getValueWithFallback(c, key, "duration", fallback, time.ParseDuration)
+}
+
+// WithTimeLayouts sets the layouts GetTime accepts, tried in order. Only
+// RFC 3339 is accepted by default.
+func WithTimeLayouts(layouts ...string) Option {
+	return func(c *Config) {
+		c.timeLayouts = append(c.timeLayouts, layouts...)
+	}
+}
+
+func (c *Config) GetTime(key string) (time.Time, error) {
+	return getValue(c, key, c.parseTime)
+}
+
+func (c *Config) GetTimeWithFallback(key string, fallback time.Time) time.Time {
+	return getValueWithFallback(c, key, "time", fallback, c.parseTime)
+}
+
+func (c *Config) parseTime(s string) (time.Time, error) {
+	layouts := c.timeLayouts
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339}
+	}
+
+	var err error
+	for _, layout := range layouts {
+		var value time.Time
+		if value, err = time.Parse(layout, s); err == nil {
+			return value, nil
+		}
+	}
+	if len(layouts) > 1 {
+		return time.Time{}, fmt.Errorf("%q matches none of the configured time layouts", s)
+	}
+	return time.Time{}, err
+}
+
+// GetURL parses an absolute URL, such as a service endpoint.
+func (c *Config) GetURL(key string) (*url.URL, error) {
+	return getValue(c, key, parseURL)
+}
+
+func (c *Config) GetURLWithFallback(key string, fallback *url.URL) *url.URL {
+	return getValueWithFallback(c, key, "URL", fallback, parseURL)
+}
+
+// WithURLKeys declares keys holding http or https endpoints, checked by
+// NewConfig so that a value such as localhost:8080 fails at startup rather
+// than on the first request.
+func WithURLKeys(keys ...string) Option {
+	return withChecks(keys, func(c *Config, value string) error {
+		_, err := parseEndpoint(value)
+		return err
+	})
+}
+
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		if !strings.Contains(s, "://") {
+			return nil, fmt.Errorf("%q is not an absolute URL, add a scheme as in %q", u.Redacted(), "http://"+strings.TrimPrefix(u.Redacted(), "//"))
+		}
+		return nil, fmt.Errorf("%q is not an absolute URL", u.Redacted())
+	}
+	return u, nil
+}
+
+// parseEndpoint parses an absolute http or https URL.
+func parseEndpoint(s string) (*url.URL, error) {
+	u, err := parseURL(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("%q is not an http or https URL", u.Redacted())
+	}
+	return u, nil
+}
+
+func getValue[T any](c *Config, key string, parse func(string) (T, error)) (T, error) {
+	var zero T
+	strValue := c.Get(key)
+	if strValue == "" {
+		return zero, fmt.Errorf("%s is not set", key)
+	}
+	value, err := parse(strValue)
+	if err != nil {
+		return zero, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func getValueWithFallback[T any](c *Config, key, kind string, fallback T, parse func(string) (T, error)) T {
+	strValue := c.Get(key)
+	if strValue == "" {
+		return fallback
+	}
+	value, err := parse(strValue)
+	if err != nil {
+		log.Printf("Warning: invalid %s value for %s, using fallback", kind, key)
+		return fallback
+	}
+	return value
+}
+
+func parseInt64(s string) (int64, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func parseUint(s string) (uint, error) {
+	value, err := strconv.ParseUint(s, 10, 0)
+	return uint(value), err
+}
+
+func parseFloat64(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCaseInsensitiveKeys(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("from-file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		fold   bool
+		key    string
+		want   string
+	}{
+		{"exact", map[string]string{"DATABASE_URL": "postgres://a"}, true, "DATABASE_URL", "postgres://a"},
+		{"different case", map[string]string{"database_url": "postgres://b"}, true, "DATABASE_URL", "postgres://b"},
+		{"folding off", map[string]string{"database_url": "postgres://b"}, false, "DATABASE_URL", ""},
+		{"exact match wins", map[string]string{"DATABASE_URL": "postgres://a", "Database_Url": "postgres://b"}, true, "DATABASE_URL", "postgres://a"},
+		{"ambiguous match is stable", map[string]string{"database_url": "postgres://a", "Database_Url": "postgres://b"}, true, "DATABASE_URL", "postgres://b"},
+		{"unset", map[string]string{"PORT": "80"}, true, "DATABASE_URL", ""},
+		// U+212A KELVIN SIGN folds to K but is three bytes long.
+		{"name longer than key", map[string]string{"\u212AEY": "kelvin"}, true, "KEY", "kelvin"},
+		{"name shorter than key", map[string]string{"KEY": "ascii"}, true, "\u212AEY", "ascii"},
+		{"file key", map[string]string{"API_TOKEN_FILE": secret}, true, "api_token", "from-file"},
+		{"file key longer than key", map[string]string{"\u212AEY_FILE": secret}, true, "key", "from-file"},
+		{"lower-case _file suffix is not a file key", map[string]string{"api_token_file": secret}, true, "API_TOKEN", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithEnvFile(emptyEnvFile(t)), WithOverrides(tt.values)}
+			if tt.fold {
+				opts = append(opts, WithCaseInsensitiveKeys())
+			}
+			c, err := LoadSnapshot(opts...)
+			if err != nil {
+				t.Fatalf("LoadSnapshot: %v", err)
+			}
+			if got := c.Get(tt.key); got != tt.want {
+				t.Errorf("Get(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyAliases(t *testing.T) {
+	type settings struct {
+		URL  string `env:"DATABASE_URL,alias=DB_URL,POSTGRES_URL"`
+		Pool int    `env:"DATABASE_POOL,alias=DB_POOL" default:"5"`
+		Host string `env:"HOST,alias=HOSTNAME,required" required:"true"`
+	}
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		fold    bool
+		want    settings
+		wantErr string
+	}{
+		{
+			name:   "primary key",
+			values: map[string]string{"DATABASE_URL": "postgres://a", "DB_URL": "postgres://b", "HOST": "h"},
+			want:   settings{URL: "postgres://a", Pool: 5, Host: "h"},
+		},
+		{
+			name:   "first alias",
+			values: map[string]string{"DB_URL": "postgres://b", "POSTGRES_URL": "postgres://c", "DB_POOL": "10", "HOSTNAME": "h"},
+			want:   settings{URL: "postgres://b", Pool: 10, Host: "h"},
+		},
+		{
+			name:   "later alias",
+			values: map[string]string{"POSTGRES_URL": "postgres://c", "HOST": "h"},
+			want:   settings{URL: "postgres://c", Pool: 5, Host: "h"},
+		},
+		{
+			name:   "aliases with case folding",
+			values: map[string]string{"postgres_url": "postgres://c", "db_pool": "7", "hostname": "h"},
+			fold:   true,
+			want:   settings{URL: "postgres://c", Pool: 7, Host: "h"},
+		},
+		{
+			name:    "aliases without case folding",
+			values:  map[string]string{"hostname": "h"},
+			wantErr: "HOST is not set",
+		},
+		{
+			name:    "invalid alias value names the alias",
+			values:  map[string]string{"DB_POOL": "many", "HOST": "h"},
+			wantErr: `invalid value for DB_POOL: strconv.ParseInt: parsing "many": invalid syntax`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []Option{WithEnvFile(emptyEnvFile(t)), WithOverrides(tt.values)}
+			if tt.fold {
+				opts = append(opts, WithCaseInsensitiveKeys())
+			}
+			got, err := Load[settings](opts...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Load = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
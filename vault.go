@@ -0,0 +1,391 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultAuth logs in to Vault and returns a client token.
+type VaultAuth interface {
+	login(ctx context.Context, s *VaultSource) (*vaultAuthInfo, error)
+}
+
+type vaultAuthInfo struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+type vaultTokenAuth struct{ token string }
+
+type vaultAppRoleAuth struct{ mount, roleID, secretID string }
+
+type vaultKubernetesAuth struct{ mount, role, tokenFile string }
+
+// VaultToken authenticates with a static token, VAULT_TOKEN when empty.
+func VaultToken(token string) VaultAuth {
+	return vaultTokenAuth{token: token}
+}
+
+func VaultAppRole(roleID, secretID string) VaultAuth {
+	return vaultAppRoleAuth{mount: "approle", roleID: roleID, secretID: secretID}
+}
+
+// VaultKubernetes authenticates with the pod's service account token
+// against the kubernetes auth method.
+func VaultKubernetes(role string) VaultAuth {
+	return vaultKubernetesAuth{mount: "kubernetes", role: role, tokenFile: serviceAccountDir + "/token"}
+}
+
+func (a vaultTokenAuth) login(ctx context.Context, s *VaultSource) (*vaultAuthInfo, error) {
+	token := a.token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("no Vault token provided and VAULT_TOKEN is not set")
+	}
+	return &vaultAuthInfo{ClientToken: token}, nil
+}
+
+func (a vaultAppRoleAuth) login(ctx context.Context, s *VaultSource) (*vaultAuthInfo, error) {
+	return s.authLogin(ctx, a.mount, map[string]string{"role_id": a.roleID, "secret_id": a.secretID})
+}
+
+func (a vaultKubernetesAuth) login(ctx context.Context, s *VaultSource) (*vaultAuthInfo, error) {
+	jwt, err := os.ReadFile(a.tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading service account token: %w", err)
+	}
+	return s.authLogin(ctx, a.mount, map[string]string{"role": a.role, "jwt": strings.TrimSpace(string(jwt))})
+}
+
+type vaultSecret struct {
+	mount string
+	path  string
+	// For dynamic database credentials.
+	key      string
+	template string
+	dynamic  bool
+}
+
+type vaultLease struct {
+	id        string
+	duration  time.Duration
+	renewable bool
+}
+
+// VaultSource reads KV v2 secrets and dynamic database credentials. Watching
+// it keeps the token and credential leases renewed and reloads when a
+// lease can no longer be extended, so new credentials are issued.
+type VaultSource struct {
+	Address   string
+	Namespace string
+	// Auth defaults to VaultToken("") and Timeout to 10s.
+	Auth    VaultAuth
+	Timeout time.Duration
+	Client  *http.Client
+
+	secrets []vaultSecret
+
+	mu     sync.Mutex
+	auth   *vaultAuthInfo
+	leases []vaultLease
+}
+
+const defaultVaultTimeout = 10 * time.Second
+
+// NewVaultSource defaults the address and namespace to VAULT_ADDR and
+// VAULT_NAMESPACE.
+func NewVaultSource(address string, auth VaultAuth) *VaultSource {
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if auth == nil {
+		auth = VaultToken("")
+	}
+	return &VaultSource{
+		Address:   strings.TrimSuffix(address, "/"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Auth:      auth,
+		Timeout:   defaultVaultTimeout,
+	}
+}
+
+// WithKV adds every field of the KV v2 secret at mount/path.
+func (s *VaultSource) WithKV(mount, path string) *VaultSource {
+	s.secrets = append(s.secrets, vaultSecret{mount: mount, path: strings.TrimPrefix(path, "/")})
+	return s
+}
+
+// WithDatabaseCredentials requests dynamic credentials from the database
+// secrets engine and sets key by replacing {{username}} and {{password}} in
+// template, e.g. "postgres://{{username}}:{{password}}@db:5432/app".
+func (s *VaultSource) WithDatabaseCredentials(mount, role, key, template string) *VaultSource {
+	s.secrets = append(s.secrets, vaultSecret{mount: mount, path: role, key: key, template: template, dynamic: true})
+	return s
+}
+
+func (s *VaultSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	if _, err := s.token(ctx); err != nil {
+		return nil, err
+	}
+
+	envs := make(map[string]string)
+	var leases []vaultLease
+	for _, secret := range s.secrets {
+		if secret.dynamic {
+			value, lease, err := s.readDatabaseCredentials(ctx, secret)
+			if err != nil {
+				return nil, err
+			}
+			envs[secret.key] = value
+			leases = append(leases, lease)
+			continue
+		}
+
+		values, err := s.readKV(ctx, secret)
+		if err != nil {
+			return nil, err
+		}
+		mergeEnvs(envs, values)
+	}
+
+	// Leases of replaced credentials are left to expire so connections
+	// still using them are not cut off.
+	s.mu.Lock()
+	s.leases = leases
+	s.mu.Unlock()
+	return envs, nil
+}
+
+func (s *VaultSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		next := s.renewInterval()
+		if !sleepContext(ctx, next) {
+			return nil
+		}
+
+		renewCtx, cancel := context.WithTimeout(ctx, s.timeout())
+		expired, err := s.renew(renewCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logWatchError("Vault", s.Address, err)
+		}
+		if expired {
+			changed()
+		}
+	}
+}
+
+func (s *VaultSource) renewInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	shortest := time.Hour
+	if s.auth != nil && s.auth.Renewable && s.auth.LeaseDuration > 0 {
+		shortest = time.Duration(s.auth.LeaseDuration) * time.Second
+	}
+	for _, lease := range s.leases {
+		if lease.renewable && lease.duration > 0 && lease.duration < shortest {
+			shortest = lease.duration
+		}
+	}
+	// Renew at two thirds of the shortest lease.
+	return shortest * 2 / 3
+}
+
+// renew extends the token and credential leases and reports whether any
+// lease could not be extended for its full duration, meaning its
+// credentials must be replaced.
+func (s *VaultSource) renew(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	auth := s.auth
+	leases := append([]vaultLease(nil), s.leases...)
+	s.mu.Unlock()
+
+	if auth != nil && auth.Renewable {
+		var resp struct {
+			Auth *vaultAuthInfo `json:"auth"`
+		}
+		if err := s.request(ctx, http.MethodPost, "/v1/auth/token/renew-self", nil, &resp); err != nil || resp.Auth == nil {
+			// Log in again; the token may have hit its max TTL.
+			s.mu.Lock()
+			s.auth = nil
+			s.mu.Unlock()
+			if _, err := s.token(ctx); err != nil {
+				return false, err
+			}
+		} else {
+			s.mu.Lock()
+			s.auth = resp.Auth
+			s.mu.Unlock()
+		}
+	}
+
+	for _, lease := range leases {
+		if !lease.renewable {
+			continue
+		}
+		var resp struct {
+			LeaseDuration int `json:"lease_duration"`
+		}
+		body := map[string]any{"lease_id": lease.id, "increment": int(lease.duration.Seconds())}
+		if err := s.request(ctx, http.MethodPut, "/v1/sys/leases/renew", body, &resp); err != nil {
+			return true, err
+		}
+		if time.Duration(resp.LeaseDuration)*time.Second < lease.duration {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *VaultSource) readKV(ctx context.Context, secret vaultSecret) (map[string]string, error) {
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := s.request(ctx, http.MethodGet, "/v1/"+secret.mount+"/data/"+secret.path, nil, &resp); err != nil {
+		return nil, fmt.Errorf("error reading Vault secret %s/%s: %w", secret.mount, secret.path, err)
+	}
+
+	envs := make(map[string]string, len(resp.Data.Data))
+	for key, value := range resp.Data.Data {
+		envs[key] = scalarString(value)
+	}
+	return envs, nil
+}
+
+func (s *VaultSource) readDatabaseCredentials(ctx context.Context, secret vaultSecret) (string, vaultLease, error) {
+	var resp struct {
+		LeaseID       string `json:"lease_id"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+		Data          struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"data"`
+	}
+	if err := s.request(ctx, http.MethodGet, "/v1/"+secret.mount+"/creds/"+secret.path, nil, &resp); err != nil {
+		return "", vaultLease{}, fmt.Errorf("error requesting database credentials for role %s: %w", secret.path, err)
+	}
+
+	value := strings.NewReplacer("{{username}}", resp.Data.Username, "{{password}}", resp.Data.Password).Replace(secret.template)
+	lease := vaultLease{
+		id:        resp.LeaseID,
+		duration:  time.Duration(resp.LeaseDuration) * time.Second,
+		renewable: resp.Renewable,
+	}
+	return value, lease, nil
+}
+
+func (s *VaultSource) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	auth := s.auth
+	s.mu.Unlock()
+	if auth != nil {
+		return auth.ClientToken, nil
+	}
+
+	login := s.Auth
+	if login == nil {
+		login = VaultToken("")
+	}
+	auth, err := login.login(ctx, s)
+	if err != nil {
+		return "", fmt.Errorf("error authenticating to Vault: %w", err)
+	}
+	s.mu.Lock()
+	s.auth = auth
+	s.mu.Unlock()
+	return auth.ClientToken, nil
+}
+
+func (s *VaultSource) authLogin(ctx context.Context, mount string, body map[string]string) (*vaultAuthInfo, error) {
+	var resp struct {
+		Auth *vaultAuthInfo `json:"auth"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/v1/auth/"+mount+"/login", "", body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Auth == nil {
+		return nil, errors.New("login response contained no token")
+	}
+	return resp.Auth, nil
+}
+
+func (s *VaultSource) request(ctx context.Context, method, path string, body, out any) error {
+	token, err := s.token(ctx)
+	if err != nil {
+		return err
+	}
+	return s.do(ctx, method, path, token, body, out)
+}
+
+func (s *VaultSource) do(ctx context.Context, method, path, token string, body, out any) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.Address+path, payload)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if s.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.Namespace)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&vaultErr)
+		if len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (s *VaultSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultVaultTimeout)
+}
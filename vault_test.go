@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVaultLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    VaultAuth
+		status  int
+		body    string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:   "KV v2 data is unwrapped",
+			auth:   VaultToken("root"),
+			status: http.StatusOK,
+			body:   `{"data":{"data":{"API_TOKEN":"s3cret","WORKERS":4,"DEBUG":true,"RATIO":0.5},"metadata":{"version":3}}}`,
+			want: map[string]string{
+				"API_TOKEN": "s3cret", "WORKERS": "4", "DEBUG": "true", "RATIO": "0.5",
+				"DATABASE_URL": "postgres://v-app:pw@db:5432/app",
+			},
+		},
+		{
+			name:   "AppRole login",
+			auth:   VaultAppRole("role-id", "secret-id"),
+			status: http.StatusOK,
+			body:   `{"data":{"data":{"API_TOKEN":"s3cret"}}}`,
+			want:   map[string]string{"API_TOKEN": "s3cret", "DATABASE_URL": "postgres://v-app:pw@db:5432/app"},
+		},
+		{
+			name:    "permission denied",
+			auth:    VaultToken("root"),
+			status:  http.StatusForbidden,
+			body:    `{"errors":["1 error occurred:\n\t* permission denied\n\n"]}`,
+			wantErr: "error reading Vault secret secret/myapp: unexpected status 403 Forbidden: 1 error occurred:\n\t* permission denied\n\n",
+		},
+		{
+			name:    "missing secret",
+			auth:    VaultToken("root"),
+			status:  http.StatusNotFound,
+			body:    `{"errors":[]}`,
+			wantErr: "error reading Vault secret secret/myapp: unexpected status 404 Not Found",
+		},
+		{
+			name:    "rejected login",
+			auth:    VaultAppRole("role-id", "wrong"),
+			wantErr: "error authenticating to Vault: unexpected status 400 Bad Request: invalid role or secret ID",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Vault-Namespace") != "team" {
+					t.Errorf("X-Vault-Namespace = %q", r.Header.Get("X-Vault-Namespace"))
+				}
+				if r.URL.Path == "/v1/auth/approle/login" {
+					var creds map[string]string
+					json.NewDecoder(r.Body).Decode(&creds)
+					if creds["role_id"] != "role-id" || creds["secret_id"] != "secret-id" {
+						w.WriteHeader(http.StatusBadRequest)
+						w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+						return
+					}
+					w.Write([]byte(`{"auth":{"client_token":"root","lease_duration":3600,"renewable":true}}`))
+					return
+				}
+				if r.Header.Get("X-Vault-Token") != "root" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				switch r.URL.Path {
+				case "/v1/secret/data/myapp":
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				case "/v1/database/creds/app":
+					w.Write([]byte(`{"lease_id":"database/creds/app/1","lease_duration":600,"renewable":true,"data":{"username":"v-app","password":"pw"}}`))
+				default:
+					t.Errorf("unexpected request %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			// A struct literal, without the constructor's timeout.
+			s := (&VaultSource{Address: srv.URL, Namespace: "team", Auth: tt.auth}).
+				WithKV("secret", "/myapp").
+				WithDatabaseCredentials("database", "app", "DATABASE_URL", "postgres://{{username}}:{{password}}@db:5432/app")
+			got, err := s.Load()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load error = %q, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, tt.want) {
+				t.Fatalf("Load = %v, %v, want %v", got, err, tt.want)
+			}
+			if len(s.leases) != 1 || s.leases[0] != (vaultLease{id: "database/creds/app/1", duration: 10 * time.Minute, renewable: true}) {
+				t.Errorf("leases = %+v", s.leases)
+			}
+		})
+	}
+}
+
+func TestVaultTokenFromEnvironment(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "env-token")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "env-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"PORT":"8080"}}}`))
+	}))
+	defer srv.Close()
+
+	// Without Auth, as in a struct literal, VAULT_TOKEN is used.
+	got, err := (&VaultSource{Address: srv.URL}).WithKV("secret", "myapp").Load()
+	if err != nil || got["PORT"] != "8080" {
+		t.Errorf("Load = %v, %v", got, err)
+	}
+
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := (&VaultSource{Address: srv.URL}).WithKV("secret", "myapp").Load(); err == nil || err.Error() != "error authenticating to Vault: no Vault token provided and VAULT_TOKEN is not set" {
+		t.Errorf("Load without a token = %v", err)
+	}
+}
+
+func TestVaultRenew(t *testing.T) {
+	tests := []struct {
+		name        string
+		granted     int
+		status      int
+		wantExpired bool
+		wantErr     string
+	}{
+		{"extended", 600, http.StatusOK, false, ""},
+		{"capped by max TTL", 120, http.StatusOK, true, ""},
+		{"lease revoked", 0, http.StatusBadRequest, true, "unexpected status 400 Bad Request: lease not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/auth/token/renew-self":
+					w.Write([]byte(`{"auth":{"client_token":"root","lease_duration":3600,"renewable":true}}`))
+				case "/v1/sys/leases/renew":
+					var body struct {
+						LeaseID   string `json:"lease_id"`
+						Increment int    `json:"increment"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					if r.Method != http.MethodPut || body.LeaseID != "database/creds/app/1" || body.Increment != 600 {
+						t.Errorf("renew request = %s %+v", r.Method, body)
+					}
+					if tt.status != http.StatusOK {
+						w.WriteHeader(tt.status)
+						w.Write([]byte(`{"errors":["lease not found"]}`))
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]int{"lease_duration": tt.granted})
+				default:
+					t.Errorf("unexpected request %s", r.URL)
+				}
+			}))
+			defer srv.Close()
+
+			s := &VaultSource{
+				Address: srv.URL,
+				auth:    &vaultAuthInfo{ClientToken: "root", LeaseDuration: 3600, Renewable: true},
+				leases:  []vaultLease{{id: "database/creds/app/1", duration: 10 * time.Minute, renewable: true}},
+			}
+			if got := s.renewInterval(); got != 400*time.Second {
+				t.Errorf("renewInterval = %v, want two thirds of the 10m lease", got)
+			}
+			expired, err := s.renew(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("renew error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("renew: %v", err)
+			}
+			if expired != tt.wantExpired {
+				t.Errorf("renew expired = %v, want %v", expired, tt.wantExpired)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func WithXMLFile(file string) Option {
+	return withFile("XML", file, decodeXML)
+}
+
+type xmlNode struct {
+	name     string
+	children map[string]any
+	text     strings.Builder
+}
+
+// decodeXML maps the children and attributes of the document root onto
+// nested keys, so <config><auth service_url="..."/></config> yields
+// AUTH_SERVICE_URL. Repeated elements become lists.
+func decodeXML(data []byte) (any, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root any
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local, children: make(map[string]any)}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				node.children[attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, node)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		case xml.EndElement:
+			node := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			var value any = node.children
+			if len(node.children) == 0 {
+				value = strings.TrimSpace(node.text.String())
+			}
+			if len(stack) == 0 {
+				root = value
+				continue
+			}
+			addXMLChild(stack[len(stack)-1].children, node.name, value)
+		}
+	}
+
+	doc, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("root element must contain child elements or attributes")
+	}
+	return doc, nil
+}
+
+func addXMLChild(children map[string]any, name string, value any) {
+	existing, exists := children[name]
+	if !exists {
+		children[name] = value
+		return
+	}
+	if list, ok := existing.([]any); ok {
+		children[name] = append(list, value)
+		return
+	}
+	children[name] = []any{existing, value}
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+func WithYAMLFile(file string) Option {
+	return withFile("YAML", file, decodeYAML)
+}
+
+func decodeYAML(data []byte) (any, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
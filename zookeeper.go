@@ -0,0 +1,382 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+// ZooKeeperSource loads the data of every znode below Path. Nested znodes
+// map like nested file keys, so /myapp/database/url becomes DATABASE_URL.
+// Watching sets ZooKeeper watches on the whole tree and reloads whenever
+// one fires.
+type ZooKeeperSource struct {
+	Servers []string
+	Path    string
+	// Digest is "user:password" for digest authentication.
+	Digest string
+	// SessionTimeout and Timeout default to 10s and 5s when not positive.
+	SessionTimeout time.Duration
+	Timeout        time.Duration
+}
+
+const (
+	defaultZooKeeperSessionTimeout = 10 * time.Second
+	defaultZooKeeperTimeout        = 5 * time.Second
+)
+
+// NewZooKeeperSource takes a connect string such as "zk1:2181,zk2:2181", as
+// used by Kafka's zookeeper.connect.
+func NewZooKeeperSource(connect, root string) *ZooKeeperSource {
+	var servers []string
+	for _, server := range strings.Split(connect, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			servers = append(servers, server)
+		}
+	}
+	return &ZooKeeperSource{
+		Servers:        servers,
+		Path:           root,
+		SessionTimeout: defaultZooKeeperSessionTimeout,
+		Timeout:        defaultZooKeeperTimeout,
+	}
+}
+
+func (s *ZooKeeperSource) Load() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	envs := make(map[string]string)
+	if err := conn.walk(s.Path, s.Path, false, envs); err != nil {
+		return nil, fmt.Errorf("error reading ZooKeeper path %s: %w", s.Path, err)
+	}
+	return envs, nil
+}
+
+func (s *ZooKeeperSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		err := s.watchOnce(ctx, changed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		logWatchError("ZooKeeper", s.Path, err)
+		if !sleepContext(ctx, 2*time.Second) {
+			return nil
+		}
+	}
+}
+
+func (s *ZooKeeperSource) watchOnce(ctx context.Context, changed func()) error {
+	dialCtx, cancel := context.WithTimeout(ctx, s.timeout())
+	conn, err := s.dial(dialCtx)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Watches fire once, so they are re-armed after every event.
+	for {
+		conn.events = 0
+		conn.conn.SetDeadline(time.Now().Add(s.timeout()))
+		if err := conn.walk(s.Path, s.Path, true, map[string]string{}); err != nil {
+			return err
+		}
+		if conn.events == 0 {
+			if err := conn.waitEvent(s.sessionTimeout() / 3); err != nil {
+				return err
+			}
+		}
+		changed()
+	}
+}
+
+const (
+	zkOpGetData     = 4
+	zkOpPing        = 11
+	zkOpGetChildren = 8
+	zkOpAuth        = 100
+
+	zkXidWatch = -1
+	zkXidPing  = -2
+	zkXidAuth  = -4
+
+	zkErrNoNode = -101
+)
+
+var errZKNoNode = errors.New("znode does not exist")
+
+type zkConn struct {
+	conn   net.Conn
+	xid    int32
+	events int
+}
+
+func (s *ZooKeeperSource) dial(ctx context.Context) (*zkConn, error) {
+	var errs []error
+	for _, server := range s.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "2181")
+		}
+		conn, err := s.connect(ctx, server)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no ZooKeeper servers configured")
+	}
+	return nil, fmt.Errorf("error connecting to ZooKeeper: %w", errors.Join(errs...))
+}
+
+func (s *ZooKeeperSource) connect(ctx context.Context, server string) (*zkConn, error) {
+	netConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", server)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+	}
+	c := &zkConn{conn: netConn}
+
+	var req bytes.Buffer
+	binary.Write(&req, binary.BigEndian, int32(0)) // protocol version
+	binary.Write(&req, binary.BigEndian, int64(0)) // last zxid seen
+	binary.Write(&req, binary.BigEndian, int32(s.sessionTimeout().Milliseconds()))
+	binary.Write(&req, binary.BigEndian, int64(0)) // session id
+	writeZKBuffer(&req, make([]byte, 16))
+	if err := c.writeFrame(req.Bytes()); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if _, err := c.readFrame(); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("%s: handshake failed: %w", server, err)
+	}
+
+	if s.Digest != "" {
+		var body bytes.Buffer
+		binary.Write(&body, binary.BigEndian, int32(0))
+		writeZKString(&body, "digest")
+		writeZKBuffer(&body, []byte(s.Digest))
+		if _, err := c.call(zkXidAuth, zkOpAuth, body.Bytes()); err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("%s: authentication failed: %w", server, err)
+		}
+	}
+	return c, nil
+}
+
+func (c *zkConn) Close() error {
+	return c.conn.Close()
+}
+
+// walk records the data of every znode below root. A znode can carry data
+// and children at once, so both are read for each node.
+func (c *zkConn) walk(root, node string, watch bool, envs map[string]string) error {
+	data, err := c.getData(node, watch)
+	if errors.Is(err, errZKNoNode) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if name := strings.Trim(strings.TrimPrefix(node, root), "/"); name != "" && len(data) > 0 {
+		envs[normalizePathKey(name)] = string(data)
+	}
+
+	children, err := c.getChildren(node, watch)
+	if errors.Is(err, errZKNoNode) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := c.walk(root, path.Join(node, child), watch, envs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *zkConn) getData(node string, watch bool) ([]byte, error) {
+	reply, err := c.call(c.nextXid(), zkOpGetData, zkPathRequest(node, watch))
+	if err != nil {
+		return nil, err
+	}
+	return readZKBuffer(bytes.NewReader(reply))
+}
+
+func (c *zkConn) getChildren(node string, watch bool) ([]string, error) {
+	reply, err := c.call(c.nextXid(), zkOpGetChildren, zkPathRequest(node, watch))
+	if err != nil {
+		return nil, err
+	}
+	r := bytes.NewReader(reply)
+	var count int32
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+		return nil, err
+	}
+	// Every child takes at least its 4-byte length, which bounds a corrupt
+	// count by the reply size.
+	children := make([]string, 0, max(min(int(count), r.Len()/4), 0))
+	for i := int32(0); i < count; i++ {
+		child, err := readZKBuffer(r)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, string(child))
+	}
+	return children, nil
+}
+
+// waitEvent blocks until a watch fires, pinging the server often enough to
+// keep the session alive.
+func (c *zkConn) waitEvent(pingInterval time.Duration) error {
+	for c.events == 0 {
+		c.conn.SetDeadline(time.Now().Add(pingInterval))
+		if _, _, err := c.readReply(); err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return err
+			}
+			c.conn.SetDeadline(time.Now().Add(pingInterval))
+			if err := c.writeFrame(zkHeader(zkXidPing, zkOpPing)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *zkConn) nextXid() int32 {
+	c.xid++
+	return c.xid
+}
+
+func (c *zkConn) call(xid, op int32, body []byte) ([]byte, error) {
+	if err := c.writeFrame(append(zkHeader(xid, op), body...)); err != nil {
+		return nil, err
+	}
+	for {
+		replyXid, reply, err := c.readReply()
+		if err != nil {
+			return nil, err
+		}
+		if replyXid == xid {
+			return reply, nil
+		}
+	}
+}
+
+// readReply reads one reply, counting watch events and skipping pings.
+func (c *zkConn) readReply() (int32, []byte, error) {
+	frame, err := c.readFrame()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(frame) < 16 {
+		return 0, nil, errors.New("short ZooKeeper reply")
+	}
+	xid := int32(binary.BigEndian.Uint32(frame))
+	code := int32(binary.BigEndian.Uint32(frame[12:]))
+	switch {
+	case xid == zkXidWatch:
+		c.events++
+		return xid, nil, nil
+	case code == zkErrNoNode:
+		return xid, nil, errZKNoNode
+	case code != 0:
+		return xid, nil, fmt.Errorf("ZooKeeper error code %d", code)
+	}
+	return xid, frame[16:], nil
+}
+
+func (c *zkConn) writeFrame(payload []byte) error {
+	frame := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	_, err := c.conn.Write(append(frame, payload...))
+	return err
+}
+
+func (c *zkConn) readFrame() ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(c.conn, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > 16<<20 {
+		return nil, fmt.Errorf("ZooKeeper frame too large (%d bytes)", n)
+	}
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(c.conn, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (s *ZooKeeperSource) sessionTimeout() time.Duration {
+	return positiveOr(s.SessionTimeout, defaultZooKeeperSessionTimeout)
+}
+
+func (s *ZooKeeperSource) timeout() time.Duration {
+	return positiveOr(s.Timeout, defaultZooKeeperTimeout)
+}
+
+func zkHeader(xid, op int32) []byte {
+	header := binary.BigEndian.AppendUint32(nil, uint32(xid))
+	return binary.BigEndian.AppendUint32(header, uint32(op))
+}
+
+func zkPathRequest(node string, watch bool) []byte {
+	var body bytes.Buffer
+	writeZKString(&body, node)
+	if watch {
+		body.WriteByte(1)
+	} else {
+		body.WriteByte(0)
+	}
+	return body.Bytes()
+}
+
+func writeZKString(w *bytes.Buffer, s string) {
+	writeZKBuffer(w, []byte(s))
+}
+
+func writeZKBuffer(w *bytes.Buffer, b []byte) {
+	binary.Write(w, binary.BigEndian, int32(len(b)))
+	w.Write(b)
+}
+
+func readZKBuffer(r *bytes.Reader) ([]byte, error) {
+	var n int32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	if int64(n) > int64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"maps"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// zkFrame prefixes payload with its length, as the server sends it.
+func zkFrame(payload []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(payload))), payload...)
+}
+
+// zkReply builds a reply frame payload: xid, zxid, error code, body.
+func zkReply(xid, code int32, body []byte) []byte {
+	reply := binary.BigEndian.AppendUint32(nil, uint32(xid))
+	reply = binary.BigEndian.AppendUint64(reply, 1)
+	reply = binary.BigEndian.AppendUint32(reply, uint32(code))
+	return append(reply, body...)
+}
+
+func zkDataBody(data string) []byte {
+	var body bytes.Buffer
+	writeZKString(&body, data)
+	body.Write(make([]byte, 68)) // stat
+	return body.Bytes()
+}
+
+func zkChildrenBody(children ...string) []byte {
+	var body bytes.Buffer
+	binary.Write(&body, binary.BigEndian, int32(len(children)))
+	for _, child := range children {
+		writeZKString(&body, child)
+	}
+	return body.Bytes()
+}
+
+// zkReplay is a connection that discards requests and replays canned
+// server output.
+type zkReplay struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c zkReplay) Read(b []byte) (int, error)  { return c.r.Read(b) }
+func (c zkReplay) Write(b []byte) (int, error) { return len(b), nil }
+
+func zkReplayConn(input []byte) *zkConn {
+	return &zkConn{conn: zkReplay{r: bytes.NewReader(input)}}
+}
+
+func TestZKReadReply(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      []byte
+		wantXid    int32
+		wantReply  []byte
+		wantEvents int
+		wantErr    error
+		wantErrMsg string
+	}{
+		{"reply", zkFrame(zkReply(3, 0, []byte("body"))), 3, []byte("body"), 0, nil, ""},
+		{"watch event", zkFrame(zkReply(zkXidWatch, 0, []byte("event"))), zkXidWatch, nil, 1, nil, ""},
+		{"no node", zkFrame(zkReply(3, zkErrNoNode, nil)), 3, nil, 0, errZKNoNode, ""},
+		{"other error", zkFrame(zkReply(3, -102, nil)), 3, nil, 0, nil, "ZooKeeper error code -102"},
+		{"short reply", zkFrame([]byte{0, 0, 0, 3}), 0, nil, 0, nil, "short ZooKeeper reply"},
+		{"truncated length", []byte{0, 0}, 0, nil, 0, io.ErrUnexpectedEOF, ""},
+		{"truncated frame", zkFrame(zkReply(3, 0, nil))[:10], 0, nil, 0, io.ErrUnexpectedEOF, ""},
+		{"frame too large", []byte{0x7f, 0xff, 0xff, 0xff}, 0, nil, 0, nil, "ZooKeeper frame too large (2147483647 bytes)"},
+		{"closed", nil, 0, nil, 0, io.EOF, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := zkReplayConn(tt.input)
+			xid, reply, err := c.readReply()
+			switch {
+			case tt.wantErrMsg != "":
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Fatalf("readReply error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("readReply error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case err != nil:
+				t.Fatalf("readReply: %v", err)
+			}
+			if xid != tt.wantXid || !bytes.Equal(reply, tt.wantReply) || c.events != tt.wantEvents {
+				t.Errorf("readReply = %d, %q with %d events, want %d, %q with %d events", xid, reply, c.events, tt.wantXid, tt.wantReply, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestZKReplyBodies(t *testing.T) {
+	var hugeCount bytes.Buffer
+	binary.Write(&hugeCount, binary.BigEndian, int32(1<<30))
+
+	tests := []struct {
+		name       string
+		body       []byte
+		children   bool
+		want       any
+		wantErrMsg string
+	}{
+		{"data", zkDataBody("postgres://db"), false, []byte("postgres://db"), ""},
+		{"null data", []byte{0xff, 0xff, 0xff, 0xff}, false, []byte(nil), ""},
+		{"data longer than the reply", []byte{0, 0, 0, 9, 'a'}, false, nil, "unexpected EOF"},
+		{"truncated data length", []byte{0, 0}, false, nil, "unexpected EOF"},
+		{"children", zkChildrenBody("database", "port"), true, []string{"database", "port"}, ""},
+		{"no children", zkChildrenBody(), true, []string{}, ""},
+		{"truncated child", zkChildrenBody("database", "port")[:12], true, nil, "unexpected EOF"},
+		{"child count larger than the reply", hugeCount.Bytes(), true, nil, "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := zkReplayConn(zkFrame(zkReply(1, 0, tt.body)))
+			var got any
+			var err error
+			if tt.children {
+				got, err = c.getChildren("/myapp", false)
+			} else {
+				got, err = c.getData("/myapp", false)
+			}
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Fatalf("error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestZooKeeperSource(t *testing.T) {
+	tree := map[string]string{
+		"/myapp":              "",
+		"/myapp/database":     "",
+		"/myapp/database/url": "postgres://db",
+		"/myapp/port":         "8080",
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveZK(conn, tree)
+		}
+	}()
+
+	// A struct literal, without the constructor's timeouts.
+	s := &ZooKeeperSource{Servers: []string{ln.Addr().String()}, Path: "/myapp"}
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if want := map[string]string{"DATABASE_URL": "postgres://db", "PORT": "8080"}; !maps.Equal(got, want) {
+		t.Errorf("Load = %v, want %v", got, want)
+	}
+
+	s.Path = "/missing"
+	if got, err := s.Load(); err != nil || len(got) != 0 {
+		t.Errorf("Load of a missing path = %v, %v, want no values", got, err)
+	}
+}
+
+// serveZK answers the handshake, getData and getChildren from tree.
+func serveZK(conn net.Conn, tree map[string]string) {
+	defer conn.Close()
+	c := &zkConn{conn: conn}
+	if _, err := c.readFrame(); err != nil {
+		return
+	}
+	if c.writeFrame(make([]byte, 36)) != nil {
+		return
+	}
+	for {
+		frame, err := c.readFrame()
+		if err != nil {
+			return
+		}
+		xid := int32(binary.BigEndian.Uint32(frame))
+		op := int32(binary.BigEndian.Uint32(frame[4:]))
+		node, _ := readZKBuffer(bytes.NewReader(frame[8:]))
+
+		data, ok := tree[string(node)]
+		var reply []byte
+		switch {
+		case !ok:
+			reply = zkReply(xid, zkErrNoNode, nil)
+		case op == zkOpGetData:
+			reply = zkReply(xid, 0, zkDataBody(data))
+		case op == zkOpGetChildren:
+			var children []string
+			for key := range tree {
+				if rest, ok := strings.CutPrefix(key, string(node)+"/"); ok && !strings.Contains(rest, "/") {
+					children = append(children, rest)
+				}
+			}
+			reply = zkReply(xid, 0, zkChildrenBody(children...))
+		default:
+			reply = zkReply(xid, -6, nil)
+		}
+		if c.writeFrame(reply) != nil {
+			return
+		}
+	}
+}